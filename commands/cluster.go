@@ -0,0 +1,129 @@
+package commands
+
+import (
+	"errors"
+	"image"
+	"strconv"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//clusterBarHeight rows of the node views bar
+const clusterBarHeight = 1
+
+//cluster an HA cluster polled node by node. The first view sums the nodes up once each has been polled, the others
+//show one node each. n and N switch views, every other key goes to all of them so they stay on the same page
+type cluster struct {
+	views  []*dashboard
+	active int
+	bar    *widgets.TabPane
+	//latest the last poll of every node, summed up into the first view. nil when it failed, round whether the node
+	//has been polled since the first view was last updated
+	latest [][]helpers.Data
+	round  []bool
+}
+
+func newCluster(layout *Layout, conf *GraphConfiguration, configs []*config.ArtifactoryDetails) (*cluster, error) {
+	c := &cluster{latest: make([][]helpers.Data, len(conf.nodes)), round: make([]bool, len(conf.nodes))}
+	names := []string{"All nodes"}
+	for _, node := range conf.nodes {
+		names = append(names, node.ID)
+	}
+	for i := range names {
+		d, err := newDashboard(layout, conf)
+		if err != nil {
+			return nil, err
+		}
+		d.config = configs[0]
+		if i > 0 {
+			d.node = conf.nodes[i-1].ID
+			d.config = helpers.NodeConfig(configs[0], conf.nodes[i-1])
+		}
+		c.views = append(c.views, d)
+	}
+	c.bar = widgets.NewTabPane(names...)
+	c.bar.Border = false
+	return c, nil
+}
+
+func (c *cluster) resize(width, height int) {
+	c.bar.SetRect(0, 0, width, clusterBarHeight)
+	for _, d := range c.views {
+		d.place(image.Rect(0, clusterBarHeight, width, height))
+	}
+}
+
+func (c *cluster) handleKey(id string) bool {
+	switch id {
+	case "n":
+		return c.selectView((c.active + 1) % len(c.views))
+	case "N":
+		return c.selectView((c.active + len(c.views) - 1) % len(c.views))
+	}
+	return keyAll(c.views, id)
+}
+
+func (c *cluster) selectView(i int) bool {
+	if i == c.active {
+		return false
+	}
+	c.active = i
+	c.bar.ActiveTabIndex = i
+	return true
+}
+
+func (c *cluster) handleControl(id string) (pollerControl, bool) {
+	return controlAll(c.views, id)
+}
+
+//apply show the poll on the view of its node, and the sum of the latest poll of every node that responded on the
+//first one. Nodes are polled independently, the sum is updated once per round, when every node has been polled since
+//the last update, so it neither jumps when the last one comes in nor feeds its rates a poll per node. The nodes left
+//out are named on the first view
+func (c *cluster) apply(p poll) {
+	if p.server < 0 || p.server >= len(c.latest) {
+		return
+	}
+	c.latest[p.server] = nil
+	if p.err == nil {
+		c.latest[p.server] = p.data
+	}
+	c.round[p.server] = true
+	c.views[p.server+1].apply(p)
+	for _, polled := range c.round {
+		if !polled {
+			return
+		}
+	}
+	for i := range c.round {
+		c.round[i] = false
+	}
+	var responded [][]helpers.Data
+	var failed []string
+	for i, data := range c.latest {
+		if data == nil {
+			failed = append(failed, c.views[i+1].node)
+			continue
+		}
+		responded = append(responded, data)
+	}
+	if len(responded) == 0 {
+		p.err = errors.New("no node responded")
+		c.views[0].apply(p)
+		return
+	}
+	p.data, p.err = helpers.AggregateNodes(responded...), nil
+	c.views[0].apply(p)
+	if len(failed) > 0 {
+		c.views[0].setFailure("Summed over " + strconv.Itoa(len(responded)) + " of " + strconv.Itoa(len(c.latest)) + " nodes, the last poll of " + strings.Join(failed, ", ") + " failed")
+	}
+}
+
+func (c *cluster) render() {
+	ui.Render(c.bar)
+	c.views[c.active].render()
+}
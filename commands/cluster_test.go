@@ -0,0 +1,323 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+	"github.com/stretchr/testify/assert"
+)
+
+//haCluster stand-ins for two HA nodes exporting different metrics, and the load balancer listing them. The nodes
+//listed in down answer with a server error
+type haCluster struct {
+	nodes []*httptest.Server
+	lb    *httptest.Server
+}
+
+func newHACluster(t *testing.T, down ...int) *haCluster {
+	c := new(haCluster)
+	for i, active := range []string{"4", "6"} {
+		text := strings.Replace(dashboardText, "jfrt_db_connections_active_total 4", "jfrt_db_connections_active_total "+active, 1)
+		status := http.StatusOK
+		for _, n := range down {
+			if n == i {
+				status = http.StatusInternalServerError
+			}
+		}
+		c.nodes = append(c.nodes, httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/artifactory/api/v1/metrics", r.URL.Path)
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			w.Write([]byte(text))
+		})))
+	}
+	c.lb = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/artifactory/"+helpers.HANodesAPI, r.URL.Path)
+		json.NewEncoder(w).Encode([]helpers.Node{
+			{ID: "art1", URL: c.nodes[0].URL + "/artifactory/", State: "RUNNING"},
+			{ID: "art2", URL: c.nodes[1].URL + "/artifactory", State: "RUNNING"},
+		})
+	}))
+	return c
+}
+
+func (c *haCluster) close() {
+	c.lb.Close()
+	for _, node := range c.nodes {
+		node.Close()
+	}
+}
+
+func (c *haCluster) config() *config.ArtifactoryDetails {
+	return &config.ArtifactoryDetails{ServerId: "ha", Url: c.lb.URL + "/artifactory/", User: "admin", Password: "password"}
+}
+
+func TestPollSources(t *testing.T) {
+	ha := newHACluster(t)
+	defer ha.close()
+	configs := []*config.ArtifactoryDetails{ha.config()}
+	conf := testGraphConfiguration()
+	assert.Equal(t, []artifactorySource{{config: configs[0]}}, pollSources(configs, conf))
+
+	var err error
+	conf.nodes, err = helpers.DiscoverNodes(configs[0])
+	assert.NoError(t, err)
+	sources := pollSources(configs, conf)
+	assert.Len(t, sources, 2)
+	assert.Equal(t, "art2", sources[1].node)
+	assert.Equal(t, ha.nodes[1].URL+"/artifactory/", sources[1].config.Url)
+
+	data, _, _, err := sources[1].fetch(0, 1)
+	assert.NoError(t, err)
+	for _, d := range data {
+		for _, m := range d.Metric {
+			assert.Equal(t, "art2", m.Labels.Get(helpers.NodeLabel), d.Name)
+		}
+	}
+}
+
+//TestClusterLoop every node polled on its own against the stand-ins, shown one by one and summed up
+func TestClusterLoop(t *testing.T) {
+	ha := newHACluster(t)
+	defer ha.close()
+	configs := []*config.ArtifactoryDetails{ha.config()}
+	conf := testGraphConfiguration()
+	var err error
+	conf.nodes, err = helpers.DiscoverNodes(configs[0])
+	assert.NoError(t, err)
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	c, err := newCluster(layout, conf, configs)
+	assert.NoError(t, err)
+	c.resize(146, 59)
+	assert.Equal(t, []string{"All nodes", "art1", "art2"}, c.bar.TabNames)
+	assert.Equal(t, clusterBarHeight, c.views[0].tabs.Min.Y)
+
+	polls := make(chan poll)
+	done := make(chan struct{})
+	defer close(done)
+	controls := startPollers(pollSources(configs, conf), time.Millisecond, polls, done)
+	events := make(chan ui.Event)
+	result := make(chan error)
+	//the summed up view is only read on the UI loop until it quits
+	summed := make(chan struct{})
+	go func() {
+		closed := false
+		result <- runDashboard(c, events, polls, controls, func(bool) {
+			if c.views[0].last != nil && !closed {
+				close(summed)
+				closed = true
+			}
+		})
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-summed:
+			waiting = false
+		case events <- ui.Event{ID: "r"}:
+		case <-time.After(5 * time.Second):
+			t.Fatal("no poll of every node")
+		}
+	}
+	events <- ui.Event{ID: "n"}
+	events <- ui.Event{ID: "2"}
+	events <- ui.Event{ID: "q"}
+	assert.NoError(t, <-result)
+
+	assert.Equal(t, 1, c.active)
+	for _, d := range c.views {
+		assert.Equal(t, 1, d.active)
+		if !assert.NotNil(t, d.last) {
+			return
+		}
+	}
+	assert.Equal(t, 4.0, newDashboardModel(c.views[1].last).DB.Active)
+	assert.Equal(t, 6.0, newDashboardModel(c.views[2].last).DB.Active)
+	all := newDashboardModel(c.views[0].last)
+	assert.Equal(t, 10.0, all.DB.Active)
+	assert.Equal(t, 200.0, all.DB.Max)
+	//both nodes see the same filestore
+	assert.Equal(t, 62.5, all.Storage.Percent)
+	assert.Equal(t, "", all.Node)
+	assert.Equal(t, "art2", newDashboardModel(c.views[2].last).Node)
+	assert.Contains(t, metaSource(c.views[1].last).text, "Node: art1 ("+ha.nodes[0].URL+"/artifactory/)")
+
+	assert.True(t, c.handleKey("N"))
+	assert.True(t, c.handleKey("N"))
+	assert.Equal(t, 2, c.active)
+	assert.False(t, c.selectView(2))
+}
+
+//TestClusterLoopNodeDown a node answering with an error is shown failing on its view and left out of the sum, the
+//session goes on
+func TestClusterLoopNodeDown(t *testing.T) {
+	ha := newHACluster(t, 0)
+	defer ha.close()
+	configs := []*config.ArtifactoryDetails{ha.config()}
+	conf := testGraphConfiguration()
+	var err error
+	conf.nodes, err = helpers.DiscoverNodes(configs[0])
+	assert.NoError(t, err)
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	c, err := newCluster(layout, conf, configs)
+	assert.NoError(t, err)
+	c.resize(146, 59)
+
+	polls := make(chan poll)
+	done := make(chan struct{})
+	defer close(done)
+	controls := startPollers(pollSources(configs, conf), time.Millisecond, polls, done)
+	events := make(chan ui.Event)
+	result := make(chan error)
+	summed := make(chan struct{})
+	go func() {
+		closed := false
+		result <- runDashboard(c, events, polls, controls, func(bool) {
+			if c.views[0].last != nil && c.views[1].failure != "" && !closed {
+				close(summed)
+				closed = true
+			}
+		})
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-summed:
+			waiting = false
+		case events <- ui.Event{ID: "r"}:
+		case err := <-result:
+			t.Fatal("the session ended: ", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no poll of every node")
+		}
+	}
+	events <- ui.Event{ID: "q"}
+	assert.NoError(t, <-result)
+
+	assert.Nil(t, c.views[1].last)
+	assert.Contains(t, c.views[1].failure, "No metrics returned by "+ha.nodes[0].URL+"/artifactory/")
+	meta := c.views[1].pages[0].panels[0].widget.(*widgets.Paragraph)
+	assert.Contains(t, meta.Text, "Node: art1 ("+ha.nodes[0].URL+"/artifactory/)")
+	assert.Contains(t, meta.Text, "No metrics returned by")
+	assert.Equal(t, "", c.views[2].failure)
+	assert.Equal(t, 6.0, newDashboardModel(c.views[2].last).DB.Active)
+
+	//the sum is the node that responded
+	all := newDashboardModel(c.views[0].last)
+	assert.Equal(t, 6.0, all.DB.Active)
+	assert.Equal(t, "Summed over 1 of 2 nodes, the last poll of art1 failed", c.views[0].failure)
+	assert.Contains(t, c.views[0].pages[0].panels[0].widget.(*widgets.Paragraph).Text, "Summed over 1 of 2 nodes")
+	assert.Contains(t, c.views[0].status.Text, "Summed over 1 of 2 nodes")
+}
+
+//TestClusterRounds the sum is updated once every node has been polled since its last update, a node polled twice
+//before the others only replaces its own data, and the summed CPU rate covers one round
+func TestClusterRounds(t *testing.T) {
+	conf := testGraphConfiguration()
+	conf.nodes = []helpers.Node{{ID: "art1"}, {ID: "art2"}}
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	c, err := newCluster(layout, conf, []*config.ArtifactoryDetails{{ServerId: "ha"}})
+	assert.NoError(t, err)
+	c.resize(146, 59)
+	nodeData := func(cpu string) []helpers.Data {
+		return parseDashboardMetrics(t, strings.Replace(modelText, "sys_cpu_totaltime_seconds 100", "sys_cpu_totaltime_seconds "+cpu, 1))
+	}
+	start := time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC)
+
+	c.apply(poll{server: 0, data: nodeData("100"), time: start})
+	assert.Nil(t, c.views[0].last)
+	c.apply(poll{server: 1, data: nodeData("100"), time: start})
+	if !assert.NotNil(t, c.views[0].last) {
+		return
+	}
+	assert.Equal(t, start, c.views[0].last.time)
+
+	//art1 twice before art2 comes in again
+	c.apply(poll{server: 0, data: nodeData("101"), time: start.Add(time.Second)})
+	c.apply(poll{server: 0, data: nodeData("102"), time: start.Add(2 * time.Second)})
+	assert.Equal(t, start, c.views[0].last.time)
+	c.apply(poll{server: 1, data: nodeData("102"), time: start.Add(2 * time.Second)})
+	assert.Equal(t, start.Add(2*time.Second), c.views[0].last.time)
+	//four CPU seconds summed over two seconds on eight processors
+	all := newDashboardModel(c.views[0].last)
+	if assert.NotNil(t, all.CPUPercent) {
+		assert.Equal(t, 25.0, *all.CPUPercent)
+	}
+}
+
+func TestRunHeadlessNodes(t *testing.T) {
+	data := parseDashboardMetrics(t, modelText)
+	pollTime := time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC)
+	polls := make(chan poll, 2)
+	polls <- poll{server: 1, data: data, time: pollTime}
+	polls <- poll{server: 0, data: data, time: pollTime}
+	conf := testGraphConfiguration()
+	conf.format, conf.polls = "line", 1
+	conf.nodes = []helpers.Node{{ID: "art1"}, {ID: "art2"}}
+	var out bytes.Buffer
+	assert.NoError(t, runHeadless(&out, ioutil.Discard, []*config.ArtifactoryDetails{{ServerId: "ha"}, {ServerId: "ha"}}, conf, polls, make(chan os.Signal)))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], " server=ha node=art2 metrics=")
+	assert.Contains(t, lines[1], " server=ha node=art1 metrics=")
+}
+
+//TestRunHeadlessNodeDown a failed poll is reported on its own and the other nodes keep going
+func TestRunHeadlessNodeDown(t *testing.T) {
+	data := parseDashboardMetrics(t, modelText)
+	pollTime := time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC)
+	polls := make(chan poll, 4)
+	polls <- poll{server: 0, err: errors.New("connection refused"), time: pollTime}
+	polls <- poll{server: 1, data: data, time: pollTime}
+	polls <- poll{server: 0, err: errors.New("connection refused"), time: pollTime.Add(time.Second)}
+	polls <- poll{server: 1, data: data, time: pollTime.Add(time.Second)}
+	conf := testGraphConfiguration()
+	conf.format, conf.polls = "line", 2
+	conf.nodes = []helpers.Node{{ID: "art1"}, {ID: "art2"}}
+	var out, errOut bytes.Buffer
+	assert.NoError(t, runHeadless(&out, &errOut, []*config.ArtifactoryDetails{{ServerId: "ha"}, {ServerId: "ha"}}, conf, polls, make(chan os.Signal)))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], " server=ha node=art2 metrics=")
+	assert.Equal(t, "2020-12-06T20:50:30Z ha node art1: poll failed: connection refused\n2020-12-06T20:50:31Z ha node art1: poll failed: connection refused\n", errOut.String())
+}
+
+//TestRunHeadlessNodeCPU the CPU utilization of a node is measured from its node-tagged counter
+func TestRunHeadlessNodeCPU(t *testing.T) {
+	pollTime := time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC)
+	polls := make(chan poll, 2)
+	polls <- poll{data: helpers.TagNode(parseDashboardMetrics(t, modelText), "art1"), time: pollTime}
+	polls <- poll{data: helpers.TagNode(parseDashboardMetrics(t, strings.Replace(modelText, "sys_cpu_totaltime_seconds 100", "sys_cpu_totaltime_seconds 102", 1)), "art1"), time: pollTime.Add(time.Second)}
+	conf := testGraphConfiguration()
+	conf.format, conf.polls = "line", 2
+	conf.nodes = []helpers.Node{{ID: "art1"}}
+	var out bytes.Buffer
+	assert.NoError(t, runHeadless(&out, ioutil.Discard, []*config.ArtifactoryDetails{{ServerId: "ha"}}, conf, polls, make(chan os.Signal)))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[1], " node=art1 ")
+		assert.Contains(t, lines[1], " cpu=50.0%")
+	}
+
+	result := testPollResult(t, modelText)
+	result.data = helpers.TagNode(result.data, "art1")
+	result.rates.Observe(result.data, result.time.Add(-time.Second))
+	result.data = helpers.TagNode(parseDashboardMetrics(t, strings.Replace(modelText, "sys_cpu_totaltime_seconds 100", "sys_cpu_totaltime_seconds 102", 1)), "art1")
+	result.rates.Observe(result.data, result.time)
+	assert.Contains(t, cpuSource(result).text, "Utilization: 50.0%")
+}
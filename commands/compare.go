@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"image"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//serverColors the color each compared server is told apart by, in --server-id order. Also the most servers compared
+var serverColors = []ui.Color{ui.ColorCyan, ui.ColorMagenta, ui.ColorGreen, ui.ColorBlue}
+
+//serverHeaderHeight rows of the server name above each column
+const serverHeaderHeight = 1
+
+//comparison one dashboard per server with the same layout, in columns next to each other. Keys go to every column so
+//they keep showing the same page, polling controls to every poller
+type comparison struct {
+	dashboards []*dashboard
+	headers    []*widgets.Paragraph
+}
+
+func newComparison(layout *Layout, conf *GraphConfiguration, configs []*config.ArtifactoryDetails) (*comparison, error) {
+	c := new(comparison)
+	for i, serverConfig := range configs {
+		d, err := newDashboard(layout, conf)
+		if err != nil {
+			return nil, err
+		}
+		d.config = serverConfig
+		d.tabs.ActiveTabStyle = ui.NewStyle(serverColors[i], ui.ColorClear, ui.ModifierBold)
+		header := widgets.NewParagraph()
+		header.Border = false
+		header.Text = "[ " + serverConfig.ServerId + " ](fg:black,bg:" + colorName(serverColors[i]) + ") " + serverConfig.Url
+		c.dashboards = append(c.dashboards, d)
+		c.headers = append(c.headers, header)
+	}
+	return c, nil
+}
+
+//resize split the terminal into one column per server, the last one takes what is left over
+func (c *comparison) resize(width, height int) {
+	columnWidth := width / len(c.dashboards)
+	for i, d := range c.dashboards {
+		x1 := (i + 1) * columnWidth
+		if i == len(c.dashboards)-1 {
+			x1 = width
+		}
+		c.headers[i].SetRect(i*columnWidth, 0, x1, serverHeaderHeight)
+		d.place(image.Rect(i*columnWidth, serverHeaderHeight, x1, height))
+	}
+}
+
+func (c *comparison) handleKey(id string) bool {
+	return keyAll(c.dashboards, id)
+}
+
+func (c *comparison) handleControl(id string) (pollerControl, bool) {
+	return controlAll(c.dashboards, id)
+}
+
+//keyAll hand a key to every dashboard, true when any of them needs a redraw
+func keyAll(dashboards []*dashboard, id string) bool {
+	redraw := false
+	for _, d := range dashboards {
+		redraw = d.handleKey(id) || redraw
+	}
+	return redraw
+}
+
+//controlAll the polling keys change the configuration all dashboards share, so only the first one handles them and
+//the others follow its pause state
+func controlAll(dashboards []*dashboard, id string) (pollerControl, bool) {
+	control, ok := dashboards[0].handleControl(id)
+	if !ok {
+		return control, false
+	}
+	for _, d := range dashboards[1:] {
+		d.paused = dashboards[0].paused
+		d.refreshMeta()
+	}
+	return control, true
+}
+
+//apply hand the poll to the dashboard of the server it was fetched from
+func (c *comparison) apply(p poll) {
+	if p.server >= 0 && p.server < len(c.dashboards) {
+		c.dashboards[p.server].apply(p)
+	}
+}
+
+func (c *comparison) render() {
+	for i, d := range c.dashboards {
+		ui.Render(c.headers[i])
+		d.render()
+	}
+}
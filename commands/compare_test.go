@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testComparison(t *testing.T) *comparison {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	c, err := newComparison(layout, testGraphConfiguration(), []*config.ArtifactoryDetails{
+		{ServerId: "a", Url: "http://a/artifactory/"},
+		{ServerId: "b", Url: "http://b/artifactory/"},
+	})
+	assert.NoError(t, err)
+	c.resize(146, 59)
+	return c
+}
+
+func TestComparisonLayout(t *testing.T) {
+	c := testComparison(t)
+	assert.Len(t, c.dashboards, 2)
+	assert.Equal(t, "[ a ](fg:black,bg:cyan) http://a/artifactory/", c.headers[0].Text)
+	assert.Equal(t, "[ b ](fg:black,bg:magenta) http://b/artifactory/", c.headers[1].Text)
+	assert.Equal(t, 0, c.headers[0].Min.X)
+	assert.Equal(t, 73, c.headers[1].Min.X)
+	assert.Equal(t, 0, c.dashboards[0].tabs.Min.X)
+	assert.Equal(t, serverHeaderHeight, c.dashboards[0].tabs.Min.Y)
+	assert.Equal(t, 73, c.dashboards[1].tabs.Min.X)
+	assert.Equal(t, 146, c.dashboards[1].tabs.Max.X)
+	for _, d := range c.dashboards {
+		for _, pn := range d.shown {
+			rect := pn.block().Rectangle
+			assert.True(t, rect.In(d.area), pn.spec.Title)
+		}
+	}
+
+	//pages switch together
+	assert.True(t, c.handleKey("4"))
+	assert.Equal(t, 3, c.dashboards[0].active)
+	assert.Equal(t, 3, c.dashboards[1].active)
+	assert.False(t, c.handleKey("4"))
+
+	//the interval is shared, it is stepped once
+	control, ok := c.handleControl("+")
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, control.interval)
+	control, ok = c.handleControl("p")
+	assert.True(t, ok)
+	assert.Equal(t, pausePolling, control.command)
+	assert.True(t, c.dashboards[1].paused)
+}
+
+//TestComparisonLoop two servers polled concurrently, every poll lands on the dashboard of its server
+func TestComparisonLoop(t *testing.T) {
+	c := testComparison(t)
+	sources := []*fakeSource{new(fakeSource), new(fakeSource)}
+	polls := make(chan poll)
+	done := make(chan struct{})
+	defer close(done)
+	var controls []chan<- pollerControl
+	for i, source := range sources {
+		control := make(chan pollerControl, 16)
+		controls = append(controls, control)
+		go pollMetrics(source, i, time.Millisecond, polls, control, done)
+	}
+
+	events := make(chan ui.Event)
+	result := make(chan error)
+	go func() {
+		result <- runDashboard(c, events, polls, controls, func(bool) {})
+	}()
+	for atomic.LoadInt32(&sources[0].calls) < 10 || atomic.LoadInt32(&sources[1].calls) < 10 {
+		for _, id := range []string{"<Tab>", "<Down>", "?", "?", "r", "1"} {
+			events <- ui.Event{ID: id}
+		}
+		events <- ui.Event{ID: "<Resize>", Payload: ui.Resize{Width: 80, Height: 24}}
+		//the keys alone keep the UI loop busy, leave it room for the polls
+		time.Sleep(time.Millisecond)
+	}
+	events <- ui.Event{ID: "q"}
+	assert.NoError(t, <-result)
+	for i, d := range c.dashboards {
+		if assert.NotNil(t, d.last) {
+			assert.Equal(t, []string{"a", "b"}[i], d.last.config.ServerId)
+		}
+		assert.NotEmpty(t, d.pools)
+	}
+	meta := c.dashboards[1].pages[0].panels[0].widget.(*widgets.Paragraph)
+	assert.Contains(t, meta.Text, "b")
+}
+
+func TestRunHeadlessServers(t *testing.T) {
+	data := parseDashboardMetrics(t, modelText)
+	pollTime := time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC)
+	polls := make(chan poll, 4)
+	polls <- poll{server: 1, data: data, time: pollTime}
+	polls <- poll{server: 0, data: data, time: pollTime}
+	polls <- poll{server: 0, data: data, time: pollTime.Add(time.Second)}
+	polls <- poll{server: 1, data: data, time: pollTime.Add(time.Second)}
+	conf := testGraphConfiguration()
+	conf.format, conf.polls = "line", 2
+	var out bytes.Buffer
+	assert.NoError(t, runHeadless(&out, ioutil.Discard, []*config.ArtifactoryDetails{{ServerId: "a"}, {ServerId: "b"}}, conf, polls, make(chan os.Signal)))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines[0], " server=b ")
+	assert.Contains(t, lines[1], " server=a ")
+	//rates are tracked per server, each one has a previous poll of its own by the second line
+	assert.Contains(t, lines[2], "cpu=")
+	assert.Contains(t, lines[3], "cpu=")
+}
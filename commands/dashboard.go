@@ -0,0 +1,458 @@
+package commands
+
+import (
+	"image"
+	"strconv"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//tabBarHeight rows taken by the page tabs when the layout has more than one page
+const tabBarHeight = 3
+
+//intervalSteps polling intervals in seconds the + and - keys step through
+var intervalSteps = []int{1, 2, 5, 10, 15, 30, 60, 120, 300}
+
+//helpText the ? overlay
+const helpText = `  q, Ctrl-C        quit
+  1-9               go to page
+  Tab, Left, Right  next / previous page
+  Up, Down, k, j    scroll the list
+  PgUp, PgDn        scroll the list a page
+  Enter             drill into the selected pool
+  Esc, b            back from the drill-down
+  [, ]              scroll the plots back / forward
+  End               back to live plots
+  p, Space          pause / resume polling
+  r                 poll now
+  +, -              poll more / less often
+  n, N              next / previous HA node view
+  ?                 show / hide this help`
+
+//page one tab of the dashboard
+type page struct {
+	name   string
+	panels []*panel
+}
+
+//dashboard every page of the layout. All pages are updated on every poll so switching pages keeps their history
+type dashboard struct {
+	pages  []*page
+	active int
+	tabs   *widgets.TabPane
+	grid   *ui.Grid
+	//shown panels of the active page that fit the terminal
+	shown []*panel
+	//area the part of the terminal the dashboard is laid out in
+	area image.Rectangle
+	//pools session stats per remote connection pool, pools the pools of the last poll in list order
+	poolStats map[string]*poolStats
+	pools     []helpers.ConnectionPool
+	//detail open pool drill-down, nil when the pages are shown
+	detail *poolDetail
+	//store history of every plotted value, plots show window worth of it ending scrolled before the latest poll
+	store    *helpers.SeriesStore
+	window   time.Duration
+	scrolled time.Duration
+	latest   time.Time
+	config   *config.ArtifactoryDetails
+	conf     *GraphConfiguration
+	rates    *helpers.RateTracker
+	//node the HA node shown, empty for a whole server or the cluster summed up
+	node string
+	//paused polling paused from the keyboard, last the latest poll, kept to show state changes between polls
+	paused bool
+	last   *pollResult
+	//help keybindings overlay, shown on top of everything when showHelp
+	help     *widgets.Paragraph
+	showHelp bool
+	//status breach status line, shown when thresholds is set. breaches the level of every panel title with
+	//thresholds, events the latest changes of those levels
+	thresholds bool
+	status     *widgets.Paragraph
+	breaches   map[string]level
+	events     []breachEvent
+	//gcRuns garbage collection runs seen this session
+	gcRuns []gcRun
+	//failure why the latest poll failed, empty when it worked. Shown in the meta pane and the status line, the panels
+	//keep showing the last poll that worked
+	failure string
+}
+
+func newDashboard(layout *Layout, conf *GraphConfiguration) (*dashboard, error) {
+	d := &dashboard{
+		grid:      ui.NewGrid(),
+		poolStats: make(map[string]*poolStats),
+		store:     helpers.NewSeriesStore(conf.retention, time.Second*time.Duration(conf.interval)),
+		window:    conf.window,
+		conf:      conf,
+		rates:     helpers.NewRateTracker(time.Minute),
+		status:    widgets.NewParagraph(),
+		breaches:  make(map[string]level),
+	}
+	var names []string
+	for i, spec := range layout.pages() {
+		pg := &page{name: spec.Name}
+		for _, panelSpec := range spec.Panels {
+			pn, err := newPanel(panelSpec)
+			if err != nil {
+				return nil, err
+			}
+			pg.panels = append(pg.panels, pn)
+			d.thresholds = d.thresholds || panelSpec.hasThresholds()
+		}
+		d.pages = append(d.pages, pg)
+		names = append(names, strconv.Itoa(i+1)+":"+spec.Name)
+	}
+	d.tabs = widgets.NewTabPane(names...)
+	d.tabs.Border = false
+	d.status.Border = false
+	d.status.Text = d.statusText()
+	d.help = widgets.NewParagraph()
+	d.help.Title = "Keys"
+	d.help.Text = helpText
+	return d, nil
+}
+
+//panels every panel of every page
+func (d *dashboard) panels() []*panel {
+	var panels []*panel
+	for _, pg := range d.pages {
+		panels = append(panels, pg.panels...)
+	}
+	return panels
+}
+
+//resize lay the active page out for a terminal of width x height
+func (d *dashboard) resize(width, height int) {
+	d.place(image.Rect(0, 0, width, height))
+}
+
+//place lay the active page out in area, a column of the terminal when comparing servers
+func (d *dashboard) place(area image.Rectangle) {
+	d.area = area
+	if len(d.pages) > 1 {
+		d.tabs.SetRect(area.Min.X, area.Min.Y, area.Max.X, area.Min.Y+tabBarHeight)
+		area.Min.Y += tabBarHeight
+	}
+	if d.statusShown() {
+		area.Max.Y -= statusLineHeight
+		d.status.SetRect(area.Min.X, area.Max.Y, area.Max.X, d.area.Max.Y)
+	}
+	d.shown = arrangeGrid(d.grid, d.pages[d.active].panels, area)
+	if d.detail != nil {
+		d.detail.grid.SetRect(d.area.Min.X, d.area.Min.Y, d.area.Max.X, d.area.Max.Y)
+	}
+	//the grid sizes the widgets when drawing, plots need their size to pick the number of points
+	d.grid.Draw(ui.NewBuffer(d.grid.GetRect()))
+	if d.detail != nil {
+		d.detail.grid.Draw(ui.NewBuffer(d.detail.grid.GetRect()))
+	}
+	d.help.Rectangle = centered(d.area, 56, strings.Count(helpText, "\n")+3)
+	d.refreshPlots()
+	helpers.LogRestFile.Debug("laid out ", d.pages[d.active].name, " for ", d.area.Dx(), "x", d.area.Dy(), ", showing ", len(d.shown), " of ", len(d.pages[d.active].panels), " panels")
+}
+
+//selectPage switch to page i, false when there is no such page
+func (d *dashboard) selectPage(i int) bool {
+	if i < 0 || i >= len(d.pages) || i == d.active {
+		return false
+	}
+	d.active = i
+	d.tabs.ActiveTabIndex = i
+	d.place(d.area)
+	return true
+}
+
+//centered width x height rectangle in the middle of area, clipped to it
+func centered(area image.Rectangle, width, height int) image.Rectangle {
+	x := area.Min.X + (area.Dx()-width)/2
+	y := area.Min.Y + (area.Dy()-height)/2
+	return image.Rect(x, y, x+width, y+height).Intersect(area)
+}
+
+//handleControl the keys that change polling: pause/resume, poll now and the interval. Returns what the poller has to
+//do, false when the key is not a polling key or changes nothing
+func (d *dashboard) handleControl(id string) (pollerControl, bool) {
+	var c pollerControl
+	switch id {
+	case "p", "<Space>":
+		d.paused = !d.paused
+		c.command = resumePolling
+		if d.paused {
+			c.command = pausePolling
+		}
+	case "r":
+		return pollerControl{command: pollNow}, true
+	case "+", "=":
+		c.command = setInterval
+		c.interval = d.stepInterval(1)
+	case "-":
+		c.command = setInterval
+		c.interval = d.stepInterval(-1)
+	default:
+		return c, false
+	}
+	if c.command == setInterval && c.interval == 0 {
+		return c, false
+	}
+	d.refreshMeta()
+	return c, true
+}
+
+//stepInterval move the polling interval to the next step up or down, 0 when already at the end
+func (d *dashboard) stepInterval(direction int) time.Duration {
+	next := 0
+	for _, step := range intervalSteps {
+		if direction > 0 && step > d.conf.interval {
+			next = step
+			break
+		}
+		if direction < 0 && step < d.conf.interval {
+			next = step
+		}
+	}
+	if next == 0 {
+		return 0
+	}
+	d.conf.interval = next
+	return time.Second * time.Duration(next)
+}
+
+//refreshMeta show a polling state change right away instead of on the next poll, which may be far off or never come
+//while paused
+func (d *dashboard) refreshMeta() {
+	if d.last == nil {
+		return
+	}
+	d.last.paused = d.paused
+	d.updateMeta(d.last)
+}
+
+func (d *dashboard) updateMeta(result *pollResult) {
+	for _, pn := range d.panels() {
+		if pn.spec.Source == "meta" {
+			pn.update(result, d.store)
+		}
+	}
+}
+
+//statusShown whether the status line takes the bottom row, for thresholds or while polls fail
+func (d *dashboard) statusShown() bool {
+	return d.thresholds || d.failure != ""
+}
+
+//setFailure show why polling fails, or that it works again when text is empty. The status line comes and goes with it
+//on layouts without thresholds
+func (d *dashboard) setFailure(text string) {
+	if text == d.failure {
+		return
+	}
+	shown := d.statusShown()
+	d.failure = text
+	if d.last != nil {
+		d.last.failure = text
+		d.updateMeta(d.last)
+	}
+	d.status.Text = d.statusText()
+	if shown != d.statusShown() && !d.area.Empty() {
+		d.place(d.area)
+	}
+}
+
+//fail record a failed poll, the session goes on with the last poll that worked
+func (d *dashboard) fail(p poll) {
+	text := "Poll failed at " + p.time.Format("15:04:05") + ": " + p.err.Error()
+	helpers.LogRestFile.Warn(d.config.ServerId, " ", d.node, " ", text)
+	d.setFailure(text)
+	if d.last == nil {
+		//nothing polled yet, the meta pane still says what is going on
+		d.updateMeta(&pollResult{node: d.node, config: d.config, conf: d.conf, time: p.time, computeTime: p.computeTime, paused: d.paused, store: d.store, failure: text})
+	}
+}
+
+//handleKey page navigation with the number keys, Tab and the left/right arrows, list scrolling with up/down, the
+//pool drill-down with Enter and Escape and the help overlay with ?. Returns true when the screen needs a redraw
+func (d *dashboard) handleKey(id string) bool {
+	if id == "?" {
+		d.showHelp = !d.showHelp
+		return true
+	}
+	if d.showHelp && id == "<Escape>" {
+		d.showHelp = false
+		return true
+	}
+	switch id {
+	case "[":
+		return d.scroll(d.window / 2)
+	case "]":
+		return d.scroll(-d.window / 2)
+	case "<End>":
+		return d.scroll(-d.scrolled)
+	}
+	if d.detail != nil {
+		switch id {
+		case "<Escape>", "<Backspace>", "b":
+			d.detail = nil
+			return true
+		}
+		return false
+	}
+	switch id {
+	case "<Up>", "k":
+		return d.scrollList((*widgets.List).ScrollUp)
+	case "<Down>", "j":
+		return d.scrollList((*widgets.List).ScrollDown)
+	case "<PageUp>":
+		return d.scrollList((*widgets.List).ScrollPageUp)
+	case "<PageDown>":
+		return d.scrollList((*widgets.List).ScrollPageDown)
+	case "<Enter>":
+		return d.openPool()
+	case "<Tab>", "<Right>":
+		return d.selectPage((d.active + 1) % len(d.pages))
+	case "<Left>":
+		return d.selectPage((d.active + len(d.pages) - 1) % len(d.pages))
+	}
+	if n, err := strconv.Atoi(id); err == nil {
+		return d.selectPage(n - 1)
+	}
+	return false
+}
+
+//scroll move the plot window back in time by by, forward when negative, within the retained history
+func (d *dashboard) scroll(by time.Duration) bool {
+	oldest, ok := d.store.Oldest()
+	if !ok {
+		return false
+	}
+	scrolled := d.scrolled + by
+	if scrolled > d.latest.Sub(oldest)-d.window {
+		scrolled = d.latest.Sub(oldest) - d.window
+	}
+	if scrolled < 0 {
+		scrolled = 0
+	}
+	if scrolled == d.scrolled {
+		return false
+	}
+	d.scrolled = scrolled
+	d.refreshPlots()
+	return true
+}
+
+//view the plot window
+func (d *dashboard) view() plotView {
+	return plotView{store: d.store, end: d.latest.Add(-d.scrolled), width: d.window, scrolled: d.scrolled}
+}
+
+//refreshPlots redraw the plot lines of every panel and the drill-down from the store
+func (d *dashboard) refreshPlots() {
+	view := d.view()
+	for _, pn := range d.panels() {
+		pn.plot(view)
+	}
+	if d.detail != nil {
+		d.detail.update(d.poolStats[d.detail.name], view)
+	}
+}
+
+//scrollList scroll the first list shown on the active page
+func (d *dashboard) scrollList(move func(*widgets.List)) bool {
+	for _, pn := range d.shown {
+		if l, ok := pn.widget.(*widgets.List); ok {
+			//termui selects row -1 when scrolling an empty list
+			if len(l.Rows) == 0 {
+				return false
+			}
+			move(l)
+			return true
+		}
+	}
+	return false
+}
+
+//openPool drill into the pool selected in the remote connections list
+func (d *dashboard) openPool() bool {
+	for _, pn := range d.shown {
+		l, ok := pn.widget.(*widgets.List)
+		if !ok || pn.spec.Source != "remote_pools" {
+			continue
+		}
+		if l.SelectedRow >= len(d.pools) {
+			return false
+		}
+		d.detail = newPoolDetail(d.pools[l.SelectedRow].Name)
+		d.place(d.area)
+		return true
+	}
+	return false
+}
+
+//apply derive everything the panels show from one poll and refresh every page. A failed poll is only reported
+func (d *dashboard) apply(p poll) {
+	if p.err != nil {
+		d.fail(p)
+		return
+	}
+	d.setFailure("")
+	//counter rates
+	d.rates.Observe(p.data, p.time)
+	d.update(&pollResult{
+		node:        d.node,
+		config:      d.config,
+		conf:        d.conf,
+		data:        p.data,
+		pools:       helpers.GetConnectionPools(p.data),
+		rates:       d.rates,
+		time:        p.time,
+		computeTime: p.computeTime,
+		lastUpdate:  p.lastUpdate,
+		offset:      p.offset,
+		paused:      d.paused,
+		failure:     d.failure,
+	})
+}
+
+//update refresh every page from one poll
+func (d *dashboard) update(result *pollResult) {
+	result.store = d.store
+	recordDisk(d.store, result)
+	d.gcRuns = recordGcRun(d.gcRuns, result)
+	result.gcRuns = d.gcRuns
+	for _, pn := range d.panels() {
+		pn.update(result, d.store)
+		pn.checkThresholds()
+	}
+	if d.thresholds {
+		d.recordBreaches(result.time)
+	}
+	d.pools = result.pools
+	recordPools(d.poolStats, d.store, result)
+	d.store.Forget(result.time.Add(-d.store.Retention))
+	d.latest = result.time
+	d.last = result
+	d.refreshPlots()
+}
+
+func (d *dashboard) render() {
+	switch {
+	case d.detail != nil:
+		ui.Render(d.detail.grid)
+	case len(d.pages) > 1:
+		ui.Render(d.tabs, d.grid)
+	default:
+		ui.Render(d.grid)
+	}
+	if d.statusShown() && d.detail == nil {
+		ui.Render(d.status)
+	}
+	if d.showHelp {
+		ui.Render(d.help)
+	}
+}
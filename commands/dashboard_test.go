@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDashboardPages(t *testing.T) {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	d, err := newDashboard(layout, testGraphConfiguration())
+	assert.NoError(t, err)
+	d.resize(146, 59)
+	assert.Equal(t, "1:Overview", d.tabs.TabNames[0])
+	assert.Equal(t, tabBarHeight, d.grid.Min.Y)
+	assert.Len(t, d.shown, 14)
+
+	assert.True(t, d.handleKey("3"))
+	assert.Equal(t, 2, d.active)
+	assert.Equal(t, 2, d.tabs.ActiveTabIndex)
+	assert.Len(t, d.shown, 5)
+	assert.False(t, d.handleKey("3"))
+	assert.False(t, d.handleKey("7"))
+	assert.False(t, d.handleKey("x"))
+
+	assert.True(t, d.handleKey("<Left>"))
+	assert.Equal(t, 1, d.active)
+	assert.True(t, d.handleKey("6"))
+	assert.True(t, d.handleKey("<Tab>"))
+	assert.Equal(t, 0, d.active)
+
+	//pages that are not shown keep collecting
+	d.update(testPollResult(t, dashboardText))
+	list := d.pages[5].panels[0].widget.(*widgets.List)
+	assert.Contains(t, list.Rows, "app_disk_free_bytes 375")
+}
+
+func TestDashboardSinglePage(t *testing.T) {
+	d, err := newDashboard(&Layout{Panels: []PanelSpec{{Type: "paragraph", Rect: []int{0, 0, 10, 10}, Source: "cpu"}}}, testGraphConfiguration())
+	assert.NoError(t, err)
+	d.resize(80, 24)
+	assert.Equal(t, 0, d.grid.Min.Y)
+	assert.False(t, d.handleKey("<Tab>"))
+}
+
+func TestDashboardPoolDrillDown(t *testing.T) {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	d, err := newDashboard(layout, testGraphConfiguration())
+	assert.NoError(t, err)
+	d.resize(146, 59)
+	assert.False(t, d.handleKey("<Enter>"))
+
+	result := testPollResult(t, dashboardText)
+	d.update(result)
+	assert.True(t, d.handleKey("<Down>"))
+	assert.True(t, d.handleKey("<Enter>"))
+	assert.Equal(t, "npm-remote", d.detail.name)
+	assert.Equal(t, 4, d.detail.gauge.Percent)
+	leased := d.detail.plots[0].Data[0]
+	assert.Equal(t, float64(2), leased[len(leased)-1])
+	max := d.detail.plots[3].Data[0]
+	assert.Equal(t, float64(50), max[len(max)-1])
+
+	//page keys are ignored while the drill-down is open
+	assert.False(t, d.handleKey("2"))
+	assert.True(t, d.handleKey("<Escape>"))
+	assert.Nil(t, d.detail)
+	assert.True(t, d.handleKey("2"))
+}
+
+//TestDashboardPoolGone the drill-down of a pool that stops being exported says so
+func TestDashboardPoolGone(t *testing.T) {
+	d := testDashboard(t)
+	result := testPollResult(t, dashboardText)
+	d.update(result)
+	assert.True(t, d.handleKey("<Down>"))
+	assert.True(t, d.handleKey("<Enter>"))
+	assert.Equal(t, "npm-remote", d.detail.name)
+	assert.NotContains(t, d.detail.summary.Text, "no longer exported")
+
+	result = testPollResult(t, strings.Replace(dashboardText, `jfrt_http_connections_leased_total{max="50",pool="npm-remote"} 2 1607287853275`, "", 1))
+	result.time = result.time.Add(time.Second)
+	d.update(result)
+	assert.Len(t, d.pools, 1)
+	assert.Equal(t, 1, d.poolStats["npm-remote"].missed)
+	assert.Contains(t, d.detail.summary.Text, "Pool npm-remote is no longer exported, last seen ")
+	assert.Contains(t, d.detail.summary.Text, "Leased:")
+
+	for i := 1; i < poolGoneAfter; i++ {
+		result.time = result.time.Add(time.Second)
+		d.update(result)
+	}
+	assert.NotContains(t, d.poolStats, "npm-remote")
+	assert.Equal(t, "Pool npm-remote is no longer exported", d.detail.summary.Text)
+}
+
+func TestRecordPools(t *testing.T) {
+	stats := make(map[string]*poolStats)
+	store := helpers.NewSeriesStore(time.Minute, time.Second)
+	result := testPollResult(t, dashboardText)
+	result.pools = []helpers.ConnectionPool{{Name: "a", Leased: 5, Pending: 3, Available: 15, Max: 20}}
+	recordPools(stats, store, result)
+	result.pools = []helpers.ConnectionPool{{Name: "a", Leased: 2, Pending: 4, Available: 18, Max: 20}}
+	result.time = result.time.Add(time.Second)
+	recordPools(stats, store, result)
+
+	s := stats["a"]
+	assert.Equal(t, helpers.ConnectionPool{Name: "a", Leased: 5, Pending: 4, Available: 18, Max: 20}, s.peak)
+	assert.Equal(t, 2, s.current.Leased)
+	assert.Equal(t, 25, s.peakUtilization)
+	leased := store.Range(poolKey("a", "Leased"), result.time.Add(-time.Minute), result.time)
+	assert.Equal(t, []helpers.Sample{{Time: result.time.Add(-time.Second), Value: 5}, {Time: result.time, Value: 2}}, leased)
+
+	pd := newPoolDetail("a")
+	view := plotView{store: store, end: result.time, width: time.Minute}
+	pd.update(s, view)
+	assert.Equal(t, 10, pd.gauge.Percent)
+	assert.Contains(t, pd.summary.Text, "Pending:            4        4")
+	pd.update(nil, view)
+	assert.Equal(t, "Pool a is no longer exported", pd.summary.Text)
+}
+
+func TestDashboardScrollWindow(t *testing.T) {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	d, err := newDashboard(layout, testGraphConfiguration())
+	assert.NoError(t, err)
+	d.resize(146, 59)
+	assert.False(t, d.handleKey("["))
+
+	result := testPollResult(t, dashboardText)
+	d.update(result)
+	result.time = result.time.Add(2 * time.Minute)
+	d.update(result)
+
+	assert.True(t, d.handleKey("["))
+	assert.Equal(t, 30*time.Second, d.scrolled)
+	assert.True(t, d.handleKey("["))
+	//two minutes of history, one minute window
+	assert.False(t, d.handleKey("["))
+	assert.Equal(t, time.Minute, d.scrolled)
+	plot := d.pages[0].panels[12].widget.(*widgets.Plot)
+	assert.Equal(t, "DB Connection Chart (1m0s ending 1m0s ago)", plot.Title)
+
+	assert.True(t, d.handleKey("<End>"))
+	assert.Equal(t, time.Duration(0), d.scrolled)
+	assert.Equal(t, "DB Connection Chart (last 1m0s)", plot.Title)
+}
+
+func TestDashboardThresholds(t *testing.T) {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	d, err := newDashboard(layout, testGraphConfiguration())
+	assert.NoError(t, err)
+	d.resize(146, 59)
+	assert.Equal(t, 58, d.grid.Max.Y)
+	assert.Equal(t, 58, d.status.Min.Y)
+
+	result := testPollResult(t, dashboardText)
+	d.update(result)
+	storage := d.pages[0].panels[4].widget.(*widgets.Gauge)
+	assert.Equal(t, 63, storage.Percent)
+	assert.Equal(t, ui.ColorGreen, storage.BarColor)
+	assert.Empty(t, d.events)
+	assert.Contains(t, d.status.Text, "No threshold breached")
+
+	result = testPollResult(t, strings.Replace(dashboardText, "app_disk_free_bytes 375", "app_disk_free_bytes 50", 1))
+	result.time = result.time.Add(time.Second)
+	d.update(result)
+	assert.Equal(t, ui.ColorRed, storage.BarColor)
+	assert.Equal(t, ui.ColorRed, storage.BorderStyle.Fg)
+	assert.Equal(t, ui.ColorRed, d.pages[3].panels[0].widget.(*widgets.Gauge).BarColor)
+	//the gauge is on two pages, the breach is reported once
+	if assert.Len(t, d.events, 1) {
+		assert.Equal(t, "20:50:31 Current Used Storage critical, 95% >= 90", d.events[0].String())
+	}
+	assert.Contains(t, d.status.Text, "1 critical")
+
+	result = testPollResult(t, strings.Replace(dashboardText, "app_disk_free_bytes 375", "app_disk_free_bytes 150", 1))
+	result.time = result.time.Add(2 * time.Second)
+	d.update(result)
+	assert.Equal(t, ui.ColorYellow, storage.BarColor)
+	result.time = result.time.Add(time.Second)
+	result.data = testPollResult(t, dashboardText).data
+	d.update(result)
+	assert.Equal(t, ui.ColorGreen, storage.BarColor)
+	if assert.Len(t, d.events, 3) {
+		assert.Equal(t, "20:50:33 Current Used Storage back to normal from warning, 63%", d.events[2].String())
+	}
+	assert.Contains(t, d.status.Text, "No threshold breached](fg:green) | 20:50:33 Current Used Storage back to normal")
+}
+
+func TestDashboardGcHistory(t *testing.T) {
+	d := testDashboard(t)
+	history := d.pages[3].panels[5].widget.(*widgets.List)
+	d.update(testPollResult(t, dashboardText))
+	assert.Equal(t, []string{"No garbage collection run seen yet"}, history.Rows)
+
+	result := testPollResult(t, modelText)
+	d.update(result)
+	result.time = result.time.Add(time.Second)
+	d.update(result)
+	assert.Len(t, d.gcRuns, 1)
+
+	failed := strings.Replace(modelText, `end="1607284801199",start="1607284800142",status="COMPLETED"`, `end="1607288401199",start="1607288400142",status="FAILED"`, -1)
+	result = testPollResult(t, strings.Replace(failed, "} 1.057", "} 2.048", 1))
+	result.time = result.time.Add(2 * time.Second)
+	d.update(result)
+	if assert.Len(t, d.gcRuns, 2) && assert.Len(t, history.Rows, 3) {
+		assert.Equal(t, "Start               Type     Status      Binaries   Cleaned  Duration Reclaimed/s", history.Rows[0])
+		assert.Equal(t, "["+d.gcRuns[1].Start.Format("2006.01.02 15:04:05")+" FULL     FAILED             0     2.0kB     2.05s     1.0kB/s ▼](fg:red)", history.Rows[1])
+		assert.Equal(t, d.gcRuns[0].Start.Format("2006.01.02 15:04:05")+" FULL     COMPLETED          0     2.0kB     1.06s     1.9kB/s  ", history.Rows[2])
+	}
+	bars := d.pages[3].panels[6].widget.(*widgets.BarChart)
+	assert.Equal(t, []string{"1", "2"}, bars.Labels)
+	assert.InDelta(t, 1000, bars.Data[1], 1e-9)
+	assert.Equal(t, "1.0kB", bars.NumFormatter(bars.Data[1]))
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	helpers "github.com/jfrog/frogvision/utils"
+)
+
+//liveSnapshot argument meaning "poll the configured server now" instead of a snapshot file
+const liveSnapshot = "live"
+
+//loadDiffSide a snapshot file saved with `metrics --raw` or `metrics --min`, or the live server
+func loadDiffSide(side string) ([]helpers.Data, error) {
+	if side != liveSnapshot {
+		data, err := helpers.LoadMetricsSnapshot(side)
+		if err != nil {
+			return nil, errors.New("Failed to load snapshot " + side + ": " + err.Error())
+		}
+		return data, nil
+	}
+	config, err := helpers.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return getMetricsData(config)
+}
+
+//metricsDiffCmd report the differences between two captures, as text (table) or json
+func metricsDiffCmd(a, b string, format string) error {
+	if format != "table" && format != "json" {
+		return errors.New("Unsupported diff format " + format + ", expected table or json")
+	}
+	before, err := loadDiffSide(a)
+	if err != nil {
+		return err
+	}
+	after, err := loadDiffSide(b)
+	if err != nil {
+		return err
+	}
+	diff := helpers.DiffMetrics(before, after)
+
+	if format == "json" {
+		jsonText, err := json.MarshalIndent(diff, "", "    ")
+		if err != nil {
+			return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
+		}
+		fmt.Println(string(jsonText))
+		return nil
+	}
+
+	fmt.Println("Families added (" + strconv.Itoa(len(diff.AddedFamilies)) + "):")
+	for _, name := range diff.AddedFamilies {
+		fmt.Println("  + " + name)
+	}
+	fmt.Println("Families removed (" + strconv.Itoa(len(diff.RemovedFamilies)) + "):")
+	for _, name := range diff.RemovedFamilies {
+		fmt.Println("  - " + name)
+	}
+	fmt.Println("Series added (" + strconv.Itoa(len(diff.AddedSeries)) + "):")
+	for _, s := range diff.AddedSeries {
+		fmt.Println("  + " + helpers.SeriesKey(s.Name, s.Labels))
+	}
+	fmt.Println("Series removed (" + strconv.Itoa(len(diff.RemovedSeries)) + "):")
+	for _, s := range diff.RemovedSeries {
+		fmt.Println("  - " + helpers.SeriesKey(s.Name, s.Labels))
+	}
+	fmt.Println("Changed series (" + strconv.Itoa(len(diff.Changed)) + "):")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, d := range diff.Changed {
+		fmt.Fprintln(tw, "  "+helpers.SeriesKey(d.Name, d.Labels)+"\t"+formatFloat(d.Before)+" -> "+formatFloat(d.After)+"\t"+signedFloat(d.Delta)+"\t"+d.PercentText)
+	}
+	return tw.Flush()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func signedFloat(f float64) string {
+	if f > 0 {
+		return "+" + formatFloat(f)
+	}
+	return formatFloat(f)
+}
@@ -0,0 +1,21 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsDiffFormat(t *testing.T) {
+	for _, format := range []string{"csv", "yaml", "openmetrics"} {
+		//rejected before either side is loaded
+		err := metricsDiffCmd("missing-a.txt", "missing-b.txt", format)
+		if assert.Error(t, err, format) {
+			assert.Equal(t, "Unsupported diff format "+format+", expected table or json", err.Error())
+		}
+	}
+	err := metricsDiffCmd("missing-a.txt", "missing-b.txt", "json")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Failed to load snapshot missing-a.txt")
+	}
+}
@@ -0,0 +1,342 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+)
+
+//store keys of the filestore usage history the forecast is fitted on
+const (
+	diskUsedKey  = "disk/used"
+	diskTotalKey = "disk/total"
+)
+
+func GetForecastCommand() components.Command {
+	return components.Command{
+		Name:        "forecast",
+		Description: "Forecast when the filestore fills up from the free disk space trend. Exits non-zero when a threshold is projected within the horizon.",
+		Aliases:     []string{"f"},
+		Arguments:   []components.Argument{},
+		Flags:       getForecastFlags(),
+		EnvVars:     []components.EnvVar{},
+		Action: func(c *components.Context) error {
+			return ForecastCmd(c)
+		},
+	}
+}
+
+func getForecastFlags() []components.Flag {
+	return []components.Flag{
+		components.StringFlag{
+			Name:         "thresholds",
+			Description:  "Comma separated used storage percentages to forecast",
+			DefaultValue: "90,100",
+		},
+		components.StringFlag{
+			Name:         "horizon",
+			Description:  "Fail when a threshold is projected within this duration, e.g. 168h",
+			DefaultValue: "168h",
+		},
+		components.StringFlag{
+			Name:        "history",
+			Description: "Recording of `metrics watch` (ndjson or csv) to fit the trend on, repeatable",
+		},
+		components.StringFlag{
+			Name:         "duration",
+			Description:  "Poll the server for this long before forecasting, e.g. 10m. 0 polls once",
+			DefaultValue: "0",
+		},
+		components.StringFlag{
+			Name:         "interval",
+			Description:  "Polling interval in seconds while sampling for --duration",
+			DefaultValue: "10",
+		},
+		components.BoolFlag{
+			Name:         "offline",
+			Description:  "Only use the --history recordings, do not poll the server",
+			DefaultValue: false,
+		},
+	}
+}
+
+type ForecastConfiguration struct {
+	thresholds []float64
+	horizon    time.Duration
+	history    []string
+	duration   time.Duration
+	interval   int
+	offline    bool
+}
+
+func getForecastConfiguration(c *components.Context) (*ForecastConfiguration, error) {
+	var conf = new(ForecastConfiguration)
+	var err error
+	conf.thresholds, err = parseThresholds(c.GetStringFlagValue("thresholds"))
+	if err != nil {
+		return nil, err
+	}
+	conf.horizon, err = time.ParseDuration(c.GetStringFlagValue("horizon"))
+	if err != nil || conf.horizon <= 0 {
+		return nil, errors.New("Invalid horizon " + c.GetStringFlagValue("horizon") + ", expected a duration such as 72h")
+	}
+	conf.history = flagValues(c, GetForecastCommand(), "history")
+	conf.duration, err = time.ParseDuration(c.GetStringFlagValue("duration"))
+	if err != nil || conf.duration < 0 {
+		return nil, errors.New("Invalid duration " + c.GetStringFlagValue("duration") + ", expected e.g. 10m")
+	}
+	conf.interval, err = strconv.Atoi(c.GetStringFlagValue("interval"))
+	if err != nil || conf.interval < 1 {
+		return nil, errors.New("Invalid interval " + c.GetStringFlagValue("interval") + ", expected a number of seconds")
+	}
+	conf.offline = c.GetBoolFlagValue("offline")
+	if conf.offline && len(conf.history) == 0 {
+		return nil, errors.New("--offline needs at least one --history recording")
+	}
+	return conf, nil
+}
+
+//parseThresholds comma separated percentages, sorted
+func parseThresholds(text string) ([]float64, error) {
+	var thresholds []float64
+	for _, field := range strings.Split(text, ",") {
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil || threshold <= 0 || threshold > 100 {
+			return nil, errors.New("Invalid threshold " + field + ", expected a percentage above 0 and at most 100")
+		}
+		thresholds = append(thresholds, threshold)
+	}
+	sort.Float64s(thresholds)
+	return thresholds, nil
+}
+
+func ForecastCmd(c *components.Context) error {
+	conf, err := getForecastConfiguration(c)
+	if err != nil {
+		return err
+	}
+
+	used, total, err := readDiskHistory(conf.history)
+	if err != nil {
+		return err
+	}
+	if !conf.offline {
+		config, err := helpers.GetConfig()
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(conf.duration)
+		for {
+			data, err := getMetricsData(config)
+			if err != nil {
+				return err
+			}
+			if u := storageUsage(&pollResult{data: data}); u != nil {
+				now := time.Now()
+				used, total = append(used, helpers.Sample{Time: now, Value: u.Used}), append(total, helpers.Sample{Time: now, Value: u.Total})
+			}
+			if !time.Now().Add(time.Second * time.Duration(conf.interval)).Before(deadline) {
+				break
+			}
+			time.Sleep(time.Second * time.Duration(conf.interval))
+		}
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i].Time.Before(used[j].Time) })
+	sort.Slice(total, func(i, j int) bool { return total[i].Time.Before(total[j].Time) })
+	if len(total) == 0 {
+		return errors.New("No app_disk_free_bytes and app_disk_total_bytes samples to forecast from")
+	}
+
+	now := used[len(used)-1].Time
+	if !conf.offline {
+		now = time.Now()
+	}
+	forecast, err := forecastDisk(used, total[len(total)-1].Value, conf.thresholds, now)
+	if err != nil {
+		return err
+	}
+	fmt.Println(forecast.text())
+	return forecast.within(conf.horizon)
+}
+
+//readDiskHistory used and total disk space samples of every recording, in time order
+func readDiskHistory(paths []string) ([]helpers.Sample, []helpers.Sample, error) {
+	var used, total []helpers.Sample
+	for _, path := range paths {
+		u, t, err := readDiskRecording(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		used, total = append(used, u...), append(total, t...)
+	}
+	sort.Slice(used, func(i, j int) bool { return used[i].Time.Before(used[j].Time) })
+	sort.Slice(total, func(i, j int) bool { return total[i].Time.Before(total[j].Time) })
+	return used, total, nil
+}
+
+//readDiskRecording used and total disk space samples of a metrics watch recording
+func readDiskRecording(path string) ([]helpers.Sample, []helpers.Sample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, errors.New("Failed to read history " + path + ": " + err.Error())
+	}
+	defer file.Close()
+	samples, err := helpers.ReadRecording(file, "app_disk_free_bytes", "app_disk_total_bytes")
+	if err != nil {
+		return nil, nil, errors.New(path + ": " + err.Error())
+	}
+	//free and total are written together on every poll
+	totals := make(map[time.Time]float64)
+	for _, s := range samples["app_disk_total_bytes"] {
+		totals[s.Time] = s.Value
+	}
+	var used, total []helpers.Sample
+	for _, s := range samples["app_disk_free_bytes"] {
+		if t, ok := totals[s.Time]; ok {
+			used = append(used, helpers.Sample{Time: s.Time, Value: t - s.Value})
+			total = append(total, helpers.Sample{Time: s.Time, Value: t})
+		}
+	}
+	return used, total, nil
+}
+
+//recordDisk keep the filestore usage of every poll in the store for the forecast panel
+func recordDisk(store *helpers.SeriesStore, result *pollResult) {
+	if u := storageUsage(result); u != nil {
+		store.Add(diskUsedKey, result.time, u.Used)
+		store.Add(diskTotalKey, result.time, u.Total)
+	}
+}
+
+//thresholdForecast when used storage reaches one threshold
+type thresholdForecast struct {
+	percent float64
+	//reached already at or above it, never not heading there
+	reached bool
+	never   bool
+	at      time.Time
+}
+
+//diskForecast the fitted used space trend and when it crosses each threshold
+type diskForecast struct {
+	trend     helpers.Trend
+	now       time.Time
+	used      float64
+	total     float64
+	forecasts []thresholdForecast
+}
+
+//forecastDisk fit the used space samples and project them against thresholds, percentages of total
+func forecastDisk(used []helpers.Sample, total float64, thresholds []float64, now time.Time) (*diskForecast, error) {
+	trend, ok := helpers.FitTrend(used)
+	if !ok || total <= 0 {
+		return nil, errors.New("Not enough storage history to forecast, need samples at two different times. Record some with metrics watch and pass them with --history, or sample for a while with --duration")
+	}
+	forecast := &diskForecast{trend: trend, now: now, used: used[len(used)-1].Value, total: total}
+	for _, percent := range thresholds {
+		f := thresholdForecast{percent: percent}
+		target := total * percent / 100
+		switch {
+		case forecast.used >= target:
+			f.reached = true
+		case trend.Slope > 0:
+			//the fitted line may already be past a target the last sample is not
+			var ok bool
+			if f.at, ok = trend.When(target, now); !ok {
+				f.at = now
+			}
+		default:
+			f.never = true
+		}
+		forecast.forecasts = append(forecast.forecasts, f)
+	}
+	return forecast, nil
+}
+
+//text the trend and one line per threshold
+func (forecast *diskForecast) text() string {
+	lines := []string{
+		"Used: " + strconv.FormatFloat(forecast.used/forecast.total*100, 'f', 1, 64) + "% (" + helpers.ByteCountDecimal(int64(forecast.used)) + " of " + helpers.ByteCountDecimal(int64(forecast.total)) + ")",
+		"Trend: " + formatGrowth(forecast.trend.Slope) + " over " + formatETA(forecast.trend.Span) + " (" + strconv.Itoa(forecast.trend.Samples) + " samples)",
+	}
+	for _, f := range forecast.forecasts {
+		line := formatNumber(f.percent) + "%: "
+		switch {
+		case f.reached:
+			line += "reached"
+		case f.never:
+			line += "not on the current trend"
+		default:
+			line += "in " + formatETA(f.at.Sub(forecast.now)) + " (" + f.at.Format("2006.01.02 15:04") + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+//within error for the first threshold reached or projected within horizon
+func (forecast *diskForecast) within(horizon time.Duration) error {
+	for _, f := range forecast.forecasts {
+		if f.reached {
+			return errors.New("Storage is already at or above " + formatNumber(f.percent) + "% used")
+		}
+		if !f.never && f.at.Sub(forecast.now) <= horizon {
+			return errors.New("Storage is projected to reach " + formatNumber(f.percent) + "% used in " + formatETA(f.at.Sub(forecast.now)) + ", within the " + formatETA(horizon) + " horizon")
+		}
+	}
+	return nil
+}
+
+//forecastSource the disk forecast from the history the dashboard kept, after the --history recordings. Those are
+//older than the retention, so they are kept aside rather than in the store
+func forecastSource(result *pollResult) panelData {
+	if result.store == nil {
+		return panelData{text: "Not available"}
+	}
+	total, ok := result.store.Last(diskTotalKey)
+	if !ok {
+		return panelData{text: "Not exported"}
+	}
+	live := result.store.Range(diskUsedKey, result.time.Add(-result.store.Retention), result.time)
+	var used []helpers.Sample
+	for _, s := range result.conf.diskHistory {
+		if len(live) == 0 || s.Time.Before(live[0].Time) {
+			used = append(used, s)
+		}
+	}
+	used = append(used, live...)
+	forecast, err := forecastDisk(used, total.Value, result.conf.forecastThresholds, result.time)
+	if err != nil {
+		return panelData{text: "Measuring the trend"}
+	}
+	return panelData{text: forecast.text()}
+}
+
+//formatGrowth bytes per second as a signed rate per hour
+func formatGrowth(perSecond float64) string {
+	perHour := perSecond * 3600
+	sign := "+"
+	if perHour < 0 {
+		sign = "-"
+	}
+	return sign + helpers.ByteCountDecimal(int64(math.Abs(perHour))) + "/h"
+}
+
+//formatETA durations of days as days and hours, shorter ones to the minute or second
+func formatETA(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return strconv.Itoa(int(d/(24*time.Hour))) + "d" + strconv.Itoa(int(d%(24*time.Hour)/time.Hour)) + "h"
+	}
+	if d >= time.Hour {
+		return d.Round(time.Minute).String()
+	}
+	return helpers.FormatAge(d)
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForecastDisk(t *testing.T) {
+	start := time.Date(2020, 12, 6, 20, 0, 0, 0, time.UTC)
+	//used grows 10 bytes an hour, from 600 of 1000
+	used := []helpers.Sample{{Time: start, Value: 580}, {Time: start.Add(time.Hour), Value: 590}, {Time: start.Add(2 * time.Hour), Value: 600}}
+	now := start.Add(2 * time.Hour)
+	forecast, err := forecastDisk(used, 1000, []float64{50, 90, 100}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "Used: 60.0% (600 B of 1.0kB)\nTrend: +10 B/h over 2h0m0s (3 samples)\n50%: reached\n90%: in 30h0m0s (2020.12.08 04:00)\n100%: in 40h0m0s (2020.12.08 14:00)", forecast.text())
+	assert.EqualError(t, forecast.within(time.Hour), "Storage is already at or above 50% used")
+
+	forecast, err = forecastDisk(used, 1000, []float64{90, 100}, now)
+	assert.NoError(t, err)
+	assert.NoError(t, forecast.within(24*time.Hour))
+	assert.EqualError(t, forecast.within(72*time.Hour), "Storage is projected to reach 90% used in 30h0m0s, within the 3d0h horizon")
+
+	shrinking := []helpers.Sample{{Time: start, Value: 600}, {Time: start.Add(time.Hour), Value: 500}}
+	forecast, err = forecastDisk(shrinking, 1000, []float64{90}, now)
+	assert.NoError(t, err)
+	assert.Contains(t, forecast.text(), "Trend: -100 B/h")
+	assert.Contains(t, forecast.text(), "90%: not on the current trend")
+	assert.NoError(t, forecast.within(72*time.Hour))
+
+	_, err = forecastDisk(used[:1], 1000, []float64{90}, now)
+	assert.Error(t, err)
+}
+
+func TestParseThresholds(t *testing.T) {
+	thresholds, err := parseThresholds("100, 90")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{90, 100}, thresholds)
+	for _, text := range []string{"", "0", "101", "90,x"} {
+		_, err := parseThresholds(text)
+		assert.Error(t, err, text)
+	}
+}
+
+func TestReadDiskRecording(t *testing.T) {
+	dir, err := ioutil.TempDir("", "forecast")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "watch.ndjson")
+	assert.NoError(t, ioutil.WriteFile(path, []byte(`{"time":"2020-12-06T20:00:00Z","name":"app_disk_free_bytes","type":"GAUGE","value":"400"}
+{"time":"2020-12-06T20:00:00Z","name":"app_disk_total_bytes","type":"GAUGE","value":"1000"}
+{"time":"2020-12-06T20:00:10Z","name":"app_disk_free_bytes","type":"GAUGE","value":"390"}
+`), 0644))
+	used, total, err := readDiskRecording(path)
+	assert.NoError(t, err)
+	//a free sample without its total is dropped
+	assert.Equal(t, []helpers.Sample{{Time: time.Date(2020, 12, 6, 20, 0, 0, 0, time.UTC), Value: 600}}, used)
+	assert.Len(t, total, 1)
+	_, _, err = readDiskRecording(filepath.Join(dir, "missing.ndjson"))
+	assert.Error(t, err)
+}
+
+func TestForecastPanel(t *testing.T) {
+	d := testDashboard(t)
+	panel := d.pages[3].panels[2]
+	assert.Equal(t, "disk_forecast", panel.spec.Source)
+	result := testPollResult(t, dashboardText)
+	result.conf = d.conf
+	result.conf.forecastThresholds = []float64{90}
+	d.update(result)
+	assert.Equal(t, "Measuring the trend", panel.widget.(*widgets.Paragraph).Text)
+
+	//free space shrinks 10 bytes a second from 375
+	for i := 1; i <= 3; i++ {
+		next := testPollResult(t, strings.Replace(dashboardText, "app_disk_free_bytes 375", "app_disk_free_bytes "+strconv.Itoa(375-10*i), 1))
+		next.conf = d.conf
+		next.time = result.time.Add(time.Duration(i) * time.Second)
+		d.update(next)
+	}
+	assert.Equal(t, "Used: 65.5% (655 B of 1.0kB)\nTrend: +36.0kB/h over 3s (4 samples)\n90%: in 25s (2020.12.06 20:50)", panel.widget.(*widgets.Paragraph).Text)
+}
+
+//TestForecastPanelHistory recordings passed with --history come before the live polls
+func TestForecastPanelHistory(t *testing.T) {
+	d := testDashboard(t)
+	panel := d.pages[3].panels[2]
+	result := testPollResult(t, dashboardText)
+	d.conf.forecastThresholds = []float64{90}
+	d.conf.diskHistory = []helpers.Sample{
+		{Time: result.time.Add(-2 * time.Hour), Value: 425},
+		{Time: result.time.Add(-time.Hour), Value: 525},
+		//the live polls win where they overlap
+		{Time: result.time.Add(time.Second), Value: 0},
+	}
+	result.conf = d.conf
+	d.update(result)
+	assert.Equal(t, "Used: 62.5% (625 B of 1.0kB)\nTrend: +100 B/h over 2h0m0s (3 samples)\n90%: in 2h45m0s (2020.12.06 23:35)", panel.widget.(*widgets.Paragraph).Text)
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	helpers "github.com/jfrog/frogvision/utils"
+)
+
+//maxGcRuns garbage collection runs kept per session
+const maxGcRuns = 500
+
+//gcHistoryBars runs shown in the reclaim rate bar chart
+const gcHistoryBars = 20
+
+//reclaimRate bytes cleaned per second of the run, 0 when the duration is unknown
+func (gc *gcRun) reclaimRate() float64 {
+	if gc.Duration <= 0 {
+		return 0
+	}
+	return gc.Cleaned / gc.Duration
+}
+
+//recordGcRun add the run of this poll when it is a new one. Artifactory keeps exporting the last run until the next
+//one, a run is identified by its start and replaced when it changes, e.g. once it completes
+func recordGcRun(runs []gcRun, result *pollResult) []gcRun {
+	gc := gcModel(result)
+	if gc == nil {
+		return runs
+	}
+	if n := len(runs); n > 0 && runs[n-1].Start.Equal(gc.Start) {
+		runs[n-1] = *gc
+		return runs
+	}
+	runs = append(runs, *gc)
+	if len(runs) > maxGcRuns {
+		runs = runs[len(runs)-maxGcRuns:]
+	}
+	return runs
+}
+
+//gcHistorySource every run seen this session, newest first, with runs that did not complete in red. The values are
+//the reclaim rate of the latest runs, oldest first, for a bar chart
+func gcHistorySource(result *pollResult) panelData {
+	var data panelData
+	if len(result.gcRuns) == 0 {
+		data.rows = []string{"No garbage collection run seen yet"}
+		return data
+	}
+	data.rows = append(data.rows, fmt.Sprintf("%-19s %-8s %-10s %9s %9s %9s %11s", "Start", "Type", "Status", "Binaries", "Cleaned", "Duration", "Reclaimed/s"))
+	for i := len(result.gcRuns) - 1; i >= 0; i-- {
+		run := result.gcRuns[i]
+		trend := " "
+		if i > 0 {
+			previous := result.gcRuns[i-1].reclaimRate()
+			if run.reclaimRate() > previous {
+				trend = "▲"
+			} else if run.reclaimRate() < previous {
+				trend = "▼"
+			}
+		}
+		row := fmt.Sprintf("%-19s %-8s %-10s %9s %9s %9s %11s %s", run.Start.Format("2006.01.02 15:04:05"), run.Type, run.Status,
+			strconv.FormatFloat(run.Binaries, 'f', -1, 64), helpers.ByteCountDecimal(int64(run.Cleaned)),
+			strconv.FormatFloat(run.Duration, 'f', 2, 64)+"s", helpers.ByteCountDecimal(int64(run.reclaimRate()))+"/s", trend)
+		if run.Status != "COMPLETED" {
+			row = "[" + row + "](fg:red)"
+		}
+		data.rows = append(data.rows, row)
+	}
+	first := len(result.gcRuns) - gcHistoryBars
+	if first < 0 {
+		first = 0
+	}
+	for i := first; i < len(result.gcRuns); i++ {
+		data.values = append(data.values, namedValue{name: result.gcRuns[i].Start.Format("15:04"), label: strconv.Itoa(i + 1), value: result.gcRuns[i].reclaimRate()})
+	}
+	return data
+}
@@ -1,23 +1,19 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
-	"math/big"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/jfrog/jfrog-cli-core/plugins/components"
-	"github.com/jfrog/jfrog-client-go/utils/errorutils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
 
 	helpers "github.com/jfrog/frogvision/utils"
 
-	"github.com/jfrog/jfrog-cli-core/utils/config"
-
 	ui "github.com/gizak/termui/v3"
-	"github.com/gizak/termui/v3/widgets"
 )
 
 type Alphabetic []string
@@ -49,6 +45,75 @@ func getGraphFlags() []components.Flag {
 			Description:  "Polling interval in seconds",
 			DefaultValue: "1",
 		},
+		components.StringFlag{
+			Name:         "layout",
+			Description:  "YAML file describing the dashboard panels, see --print-layout for the built-in one",
+		},
+		components.BoolFlag{
+			Name:         "print-layout",
+			Description:  "Print the built-in dashboard layout and exit",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:         "retention",
+			Description:  "How much history to keep in memory for the plots, e.g. 15m or 3h",
+			DefaultValue: "15m",
+		},
+		components.StringFlag{
+			Name:         "window",
+			Description:  "Time span shown by the plots, scroll back through the retained history with [ and ]",
+			DefaultValue: "5m",
+		},
+		components.StringFlag{
+			Name:        "metric",
+			Description: "Plot the series matching a selector instead of the dashboard, repeat for several plots, e.g. --metric 'jfrt_http_connections_.*{pool=\"npm-remote\"}'",
+		},
+		components.BoolFlag{
+			Name:         "headless",
+			Description:  "Print the dashboard values once per poll instead of drawing them, for CI logs and cron jobs",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:         "format",
+			Description:  "Headless output, line or json",
+			DefaultValue: "line",
+		},
+		components.StringFlag{
+			Name:         "polls",
+			Description:  "Headless only, stop after this many polls, 0 to run until interrupted",
+			DefaultValue: "0",
+		},
+		components.StringFlag{
+			Name:         "forecast-thresholds",
+			Description:  "Comma separated used storage percentages the Storage & GC page forecasts",
+			DefaultValue: "90,100",
+		},
+		components.StringFlag{
+			Name:        "history",
+			Description: "A metrics watch recording (ndjson or csv) the Storage & GC forecast fits along with the live polls, repeat for several",
+		},
+		components.StringFlag{
+			Name:        "server-id",
+			Description: "Server id of the JFrog CLI config to graph instead of the default one, repeat to compare up to 4 servers side by side",
+		},
+		components.StringFlag{
+			Name:        "node",
+			Description: "Poll one node of an HA cluster on its own, as id=url or url, repeat for every node. Shows every node summed up and each node, n switches",
+		},
+		components.BoolFlag{
+			Name:         "discover-nodes",
+			Description:  "Poll every node of an HA cluster on its own, listed by the cluster's HA nodes API",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:        "threshold",
+			Description: "Warn and critical thresholds of a panel by title, repeat for several panels, e.g. --threshold \"Current Used Storage=80,90\". Leave one empty to drop it",
+		},
+		components.StringFlag{
+			Name:         "stale-after",
+			Description:  "Flag metrics in the Meta statistics pane whose UPDATED time is older than this many seconds",
+			DefaultValue: "300",
+		},
 	}
 }
 
@@ -57,507 +122,221 @@ func getGraphEnvVar() []components.EnvVar {
 }
 
 type GraphConfiguration struct {
-	interval int
+	interval   int
+	staleAfter time.Duration
+	layout     string
+	metrics    []string
+	serverIDs  []string
+	headless   bool
+	format     string
+	polls      int
+	retention  time.Duration
+	window     time.Duration
+	//nodes the HA nodes polled one by one, none to poll the server URL
+	nodes []helpers.Node
+	//thresholds panel threshold overrides, title=warn,critical
+	thresholds []string
+	//forecastThresholds used storage percentages of the disk forecast
+	forecastThresholds []float64
+	//diskHistory used storage of the --history recordings, in time order, fitted by the forecast before the live polls
+	diskHistory []helpers.Sample
 }
 
 func GraphCmd(c *components.Context) error {
 
+	if c.GetBoolFlagValue("print-layout") {
+		fmt.Print(defaultLayout)
+		return nil
+	}
+
+	var conf = new(GraphConfiguration)
+	conf.layout = c.GetStringFlagValue("layout")
+	conf.metrics = flagValues(c, GetGraphCommand(), "metric")
+	if len(conf.metrics) > 0 && conf.layout != "" {
+		return errors.New("Use either --metric or --layout")
+	}
 	interval, err := strconv.Atoi(c.GetStringFlagValue("interval"))
+	if err != nil || interval < 1 {
+		return errors.New("Invalid interval " + c.GetStringFlagValue("interval") + ", expected a number of seconds")
+	}
+	conf.interval = interval
+	staleAfter, err := strconv.Atoi(c.GetStringFlagValue("stale-after"))
+	if err != nil || staleAfter < 1 {
+		return errors.New("Invalid stale-after " + c.GetStringFlagValue("stale-after") + ", expected a number of seconds")
+	}
+	conf.staleAfter = time.Second * time.Duration(staleAfter)
+	conf.retention, err = time.ParseDuration(c.GetStringFlagValue("retention"))
+	if err != nil || conf.retention <= 0 {
+		return errors.New("Invalid retention " + c.GetStringFlagValue("retention") + ", expected a duration such as 15m or 2h")
+	}
+	conf.window, err = time.ParseDuration(c.GetStringFlagValue("window"))
+	if err != nil || conf.window <= 0 || conf.window > conf.retention {
+		return errors.New("Invalid window " + c.GetStringFlagValue("window") + ", expected a duration such as 5m, at most the retention")
+	}
 
-	config, err := helpers.GetConfig()
+	conf.forecastThresholds, err = parseThresholds(c.GetStringFlagValue("forecast-thresholds"))
 	if err != nil {
 		return err
 	}
-
-	if err := ui.Init(); err != nil {
-		fmt.Printf("failed to initialize termui: %v", err)
+	conf.diskHistory, _, err = readDiskHistory(flagValues(c, GetGraphCommand(), "history"))
+	if err != nil {
 		return err
 	}
-	defer ui.Close()
-
-	//Meta statistics
-	o := widgets.NewParagraph()
-	o.Title = "Meta statistics"
-	o.Text = "Current time: " + time.Now().Format("2006.01.02 15:04:05")
-	o.SetRect(0, 0, 77, 6)
-
-	p := widgets.NewParagraph()
-	p.Title = "Total Remote Conns"
-	p.Text = "Initializing"
-	p.SetRect(0, 6, 25, 11)
-
-	q := widgets.NewParagraph()
-	q.Title = "CPU Time (seconds)"
-	q.Text = "Initializing"
-	q.SetRect(26, 6, 51, 11)
-
-	r := widgets.NewParagraph()
-	r.Title = "Number of Metrics"
-	r.Text = "Initializing"
-	r.SetRect(52, 6, 77, 11)
-
-	//GC statistics
-	o2 := widgets.NewParagraph()
-	o2.Title = "Garbage Collection statistics"
-	o2.Text = "Initializing"
-	o2.SetRect(0, 45, 77, 51)
-
-	g2 := widgets.NewGauge()
-	g2.Title = "Current Used Storage"
-	g2.SetRect(0, 11, 36, 14)
-	g2.Percent = 0
-	g2.BarColor = ui.ColorGreen
-	g2.LabelStyle = ui.NewStyle(ui.ColorBlue)
-	g2.BorderStyle.Fg = ui.ColorWhite
-
-	g3 := widgets.NewGauge()
-	g3.Title = "Current Used Heap"
-	g3.SetRect(0, 14, 36, 17)
-	g3.Percent = 0
-	g3.BarColor = ui.ColorGreen
-	g3.LabelStyle = ui.NewStyle(ui.ColorBlue)
-	g3.BorderStyle.Fg = ui.ColorWhite
-
-	//DB connections
-	g4 := widgets.NewGauge()
-	g4.Title = "Active DB connections"
-	g4.SetRect(0, 17, 36, 20)
-	g4.Percent = 0
-	g4.BarColor = ui.ColorGreen
-	g4.LabelStyle = ui.NewStyle(ui.ColorBlue)
-	g4.BorderStyle.Fg = ui.ColorWhite
-
-	//DB connection plot chart
-	p1 := widgets.NewPlot()
-	p1.Title = "DB Connection Chart"
-	//p1.Marker = widgets.MarkerDot
-
-	var dbActivePlotData = make([]float64, 60)
-	var dbMaxPlotData = make([]float64, 60)
-	var dbIdlePlotData = make([]float64, 60)
-	var dbMinIdlePlotData = make([]float64, 60)
-	var dbConnPlotData = [][]float64{dbActivePlotData, dbMaxPlotData, dbIdlePlotData, dbMinIdlePlotData}
-
-	for i := 0; i < 60; i++ {
-		dbActivePlotData[i] = 0
-		dbMaxPlotData[i] = 0
-		dbIdlePlotData[i] = 0
-		dbMinIdlePlotData[i] = 0
-	}
-	p1.Data = dbConnPlotData
-	p1.SetRect(78, 0, 146, 28)
-	p1.DotMarkerRune = '.'
-	p1.AxesColor = ui.ColorWhite
-	p1.LineColors[0] = ui.ColorBlack
-	p1.LineColors[1] = ui.ColorGreen
-	p1.LineColors[2] = ui.ColorBlue
-	p1.LineColors[3] = ui.ColorRed
-	p1.DrawDirection = widgets.DrawLeft
-	p1.HorizontalScale = 1
-
-	//Remote connection plot chart
-	var rcPlotData = make(map[string][]float64)
-	p2 := widgets.NewPlot()
-	p2.Title = "Remote Connections Chart"
-	//p2.Marker = widgets.MarkerDot
-
-	var leasedPlotData = make([]float64, 60)
-	var connPlotData = [][]float64{leasedPlotData}
-
-	for i := 0; i < 60; i++ {
-		leasedPlotData[i] = 0
-	}
-	p2.Data = connPlotData
-	p2.SetRect(78, 28, 146, 56)
-	p2.DotMarkerRune = '+'
-	p2.AxesColor = ui.ColorWhite
-	p2.DrawDirection = widgets.DrawLeft
-	p2.HorizontalScale = 1
-
-	//bar chart
-	barchartData := []float64{1, 1, 1, 1}
-
-	bc := widgets.NewBarChart()
-	bc.Title = "DB Connections"
-	bc.BarWidth = 5
-	bc.Data = barchartData
-	bc.SetRect(0, 20, 36, 34)
-	bc.Labels = []string{"Active", "Max", "Idle", "MinIdle"}
-	bc.BarColors[0] = ui.ColorBlack
-	bc.BarColors[1] = ui.ColorGreen
-	bc.BarColors[2] = ui.ColorBlue
-	bc.BarColors[3] = ui.ColorRed
-	bc.LabelStyles[0] = ui.NewStyle(ui.ColorWhite)
-	bc.LabelStyles[1] = ui.NewStyle(ui.ColorWhite)
-	bc.LabelStyles[2] = ui.NewStyle(ui.ColorWhite)
-	bc.LabelStyles[3] = ui.NewStyle(ui.ColorWhite)
-	bc.NumStyles[0] = ui.NewStyle(ui.ColorBlack)
-
-	//remote conn barchart
-	bc2 := widgets.NewBarChart()
-	bc2.Title = "Remote Connections Barchart"
-	bc2.BarWidth = 3
-	bc2.Data = []float64{}
-	bc2.SetRect(0, 34, 77, 45)
-	bc2.Labels = []string{}
-	bc2.BarColors[0] = ui.ColorGreen
-	bc2.NumStyles[0] = ui.NewStyle(ui.ColorBlack)
-
-	//remote connections list
-	l := widgets.NewList()
-	l.Title = "Remote Connections List"
-	l.Rows = []string{}
-	l.TextStyle = ui.NewStyle(ui.ColorYellow)
-	l.WrapText = false
-	l.SetRect(37, 11, 77, 34)
-
-	ui.Render(bc, bc2, g2, g3, g4, l, o, o2, p, p1, p2, q, r)
-
-	uiEvents := ui.PollEvents()
-	ticker := time.NewTicker(time.Second * time.Duration(interval)).C
-	offSetCounter := 0
-	tickerCount := 1
-
-	go func() {
-		for {
-			if time.Now().Second() == 0 {
-				for i := range rcPlotData {
-					rcPlotData[i] = make([]float64, 60)
-				}
-				for i := range dbConnPlotData {
-					dbConnPlotData[i] = make([]float64, 60)
-				}
-				helpers.LogRestFile.Info("reset graphs")
-			}
-			time.Sleep(time.Second * time.Duration(1))
-		}
-	}()
-
-	for {
-		select {
-		case e := <-uiEvents:
-			switch e.ID { // event string/identifier
-			case "q", "<C-c>": // press 'q' or 'C-c' to quit
-				return nil
-			}
+	conf.headless = c.GetBoolFlagValue("headless")
+	conf.format = c.GetStringFlagValue("format")
+	if conf.format != "line" && conf.format != "json" {
+		return errors.New("Unsupported headless format " + conf.format + ", expected line or json")
+	}
+	conf.polls, err = strconv.Atoi(c.GetStringFlagValue("polls"))
+	if err != nil || conf.polls < 0 {
+		return errors.New("Invalid polls " + c.GetStringFlagValue("polls") + ", expected a number")
+	}
 
-		// use Go's built-in tickers for updating and drawing data
-		case <-ticker:
-			var err error
-			offSetCounter, rcPlotData, err = drawFunction(config, bc, bc2, barchartData, g2, g3, g4, l, o, o2, p, p1, dbConnPlotData, p2, rcPlotData, q, r, offSetCounter, tickerCount, interval)
-			if err != nil {
-				return errorutils.CheckError(err)
-			}
-			tickerCount++
+	conf.thresholds = flagValues(c, GetGraphCommand(), "threshold")
 
-		}
+	conf.serverIDs = flagValues(c, GetGraphCommand(), "server-id")
+	configs, err := getServerConfigs(conf.serverIDs)
+	if err != nil {
+		return err
 	}
-}
-
-func drawFunction(config *config.ArtifactoryDetails, bc *widgets.BarChart, bc2 *widgets.BarChart, bcData []float64, g2 *widgets.Gauge, g3 *widgets.Gauge, g4 *widgets.Gauge, l *widgets.List, o *widgets.Paragraph, o2 *widgets.Paragraph, p *widgets.Paragraph, p1 *widgets.Plot, plotData [][]float64, p2 *widgets.Plot, rcPlotData map[string][]float64, q *widgets.Paragraph, r *widgets.Paragraph, offSetCounter int, ticker int, interval int) (int, map[string][]float64, error) {
-	responseTime := time.Now()
-	data, lastUpdate, offset, err := helpers.GetMetricsData(config, offSetCounter, false, interval)
+	conf.nodes, err = getNodes(c, configs)
 	if err != nil {
-		return 0, nil, err
-
+		return err
 	}
-	responseTimeCompute := time.Now()
-
-	file2, _ := os.OpenFile(helpers.LogFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	helpers.LogRestFile.Out = file2
-
-	var freeSpace, totalSpace, heapFreeSpace, heapMaxSpace, heapTotalSpace *big.Float = big.NewFloat(1), big.NewFloat(100), big.NewFloat(100), big.NewFloat(100), big.NewFloat(100)
-	var heapProc string
-	var dbConnIdle, dbConnMinIdle, dbConnActive, dbConnMax, gcBinariesTotal, gcDurationSecs, lastGcRun string
-	var gcSizeCleanedBytes, gcCurrentSizeBytes *big.Float = big.NewFloat(0), big.NewFloat(0)
-
-	//maybe we can turn this into a hashtable for faster lookup
-	//remote connection specifc
-	var remoteConnMap []helpers.Data
-
-	var remoteConnMap2 = make(map[string]helpers.Data)
-	var remoteConnMapIds = []string{}
-
-	for i := range data {
-
-		var err error
-		switch dataArg := data[i].Name; dataArg {
-		case "sys_cpu_totaltime_seconds":
-			q.Text = data[i].Metric[0].Value
-		case "jfrt_runtime_heap_maxmemory_bytes":
-			heapMaxSpace, _, err = big.ParseFloat(data[i].Metric[0].Value, 10, 0, big.ToNearestEven)
-			if err != nil {
-				//prevent cannot divide by zero error for all heap/space floats to prevent remote connection crashes
-				heapMaxSpace = big.NewFloat(1)
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-		case "jfrt_runtime_heap_freememory_bytes":
-			heapFreeSpace, _, err = big.ParseFloat(data[i].Metric[0].Value, 10, 0, big.ToNearestEven)
-			if err != nil {
-				heapFreeSpace = big.NewFloat(1)
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-		case "jfrt_runtime_heap_totalmemory_bytes":
-			heapTotalSpace, _, err = big.ParseFloat(data[i].Metric[0].Value, 10, 0, big.ToNearestEven)
-			if err != nil {
-				heapTotalSpace = big.NewFloat(1)
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-		case "jfrt_runtime_heap_processors_total":
-			heapProc = data[i].Metric[0].Value
-		case "app_disk_free_bytes":
-			freeSpace, _, err = big.ParseFloat(data[i].Metric[0].Value, 10, 0, big.ToNearestEven)
-			if err != nil {
-				freeSpace = big.NewFloat(1)
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-		case "app_disk_total_bytes":
-			totalSpace, _, err = big.ParseFloat(data[i].Metric[0].Value, 10, 0, big.ToNearestEven)
-			if err != nil {
-				totalSpace = big.NewFloat(1)
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-		case "jfrt_db_connections_active_total":
-			dbConnActive = data[i].Metric[0].Value
-		case "jfrt_db_connections_max_active_total":
-			dbConnMax = data[i].Metric[0].Value
-		case "jfrt_db_connections_min_idle_total":
-			dbConnMinIdle = data[i].Metric[0].Value
-		case "jfrt_db_connections_idle_total":
-			dbConnIdle = data[i].Metric[0].Value
-		case "jfrt_artifacts_gc_duration_seconds":
-			gcDurationSecs = data[i].Metric[0].Value
-
-			gcStart, err := strconv.ParseInt(data[i].Metric[0].Labels.Start, 10, 64)
-			if err != nil {
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-			startTimeEpoch := time.Unix(gcStart/1000, 0)
-			gcEnd, err := strconv.ParseInt(data[i].Metric[0].Labels.End, 10, 64)
-			if err != nil {
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-			endTimeEpoch := time.Unix(gcEnd/1000, 0)
-
-			lastGcRun = "Last GC Run:" + startTimeEpoch.Format("2006.01.02 15:04:05") + " -> " + endTimeEpoch.Format("2006.01.02 15:04:05") + "\nType: " + data[i].Metric[0].Labels.Type + " Status: " + data[i].Metric[0].Labels.Status
-		case "jfrt_artifacts_gc_size_cleaned_bytes":
-			gcSizeCleanedBytes, _, err = big.ParseFloat(data[i].Metric[0].Value, 10, 0, big.ToNearestEven)
-			if err != nil {
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-				gcSizeCleanedBytes = big.NewFloat(1)
-			}
-		case "jfrt_artifacts_gc_binaries_total":
-			gcBinariesTotal = data[i].Metric[0].Value
-		case "jfrt_artifacts_gc_current_size_bytes":
-			gcCurrentSizeBytes, _, err = big.ParseFloat(data[i].Metric[0].Value, 10, 0, big.ToNearestEven)
-			if err != nil {
-				gcCurrentSizeBytes = big.NewFloat(1)
-				helpers.LogRestFile.Error(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
-			}
-
-		default:
-			// do nothing
-		}
-		//gc
-		var gcSizeCleanedBytesBigInt, gcCurrentSizeBytesBigInt = new(big.Int), new(big.Int)
-		gcSizeCleanedBytesBigInt, _ = gcSizeCleanedBytes.Int(gcSizeCleanedBytesBigInt)
-		gcCurrentSizeBytesBigInt, _ = gcCurrentSizeBytes.Int(gcCurrentSizeBytesBigInt)
-		gcSizeCleanedBytesStr := gcSizeCleanedBytesBigInt.String()
-		gcCurrentSizeBytesStr := gcCurrentSizeBytesBigInt.String()
-
-		o2.Text = lastGcRun + "\nNumber of binaries cleaned: " + gcBinariesTotal + " Duration: " + gcDurationSecs + "s\nCleaned up: " + helpers.ByteCountDecimal(helpers.StringToInt64(gcSizeCleanedBytesStr)) + " Current size: " + helpers.ByteCountDecimal(helpers.StringToInt64(gcCurrentSizeBytesStr))
-
-		//repo specific connection check
-		if strings.Contains(data[i].Name, "jfrt_http_connections") {
-			remoteConnMap2[data[i].Name] = data[i]
-			remoteConnMapIds = append(remoteConnMapIds, data[i].Name)
-		}
+	if conf.headless {
+		return graphHeadless(configs, conf)
 	}
 
-	sort.Sort(Alphabetic(remoteConnMapIds))
-	for i := range remoteConnMapIds {
-		somedata := remoteConnMap2[remoteConnMapIds[i]]
-		remoteConnMap = append(remoteConnMap, somedata)
+	var layout *Layout
+	if len(conf.metrics) > 0 {
+		layout, err = metricsLayout(conf.metrics)
+	} else {
+		layout, err = loadLayout(conf.layout)
 	}
-
-	//heapMax is xmx confirmed. no idea what the other two are
-	//2.07e8, 4.29e09, 1.5e09
-	//fmt.Println(heapFreeSpace, heapMaxSpace, heapTotalSpace)
-
-	//compute DB active guage
-	dbConnActiveInt, err := strconv.Atoi(dbConnActive)
-	if err != nil {
-		dbConnActiveInt = 0
-		//return 0, errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+	if err == nil {
+		err = layout.overrideThresholds(conf.thresholds)
 	}
-	dbConnMaxInt, err := strconv.Atoi(dbConnMax)
 	if err != nil {
-		//prevent integer divide by zero error
-		dbConnMaxInt = 1
-		//return 0, errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+		return err
 	}
-	dbConnIdleInt, err := strconv.Atoi(dbConnIdle)
-	if err != nil {
-		dbConnIdleInt = 0
-		//return 0, errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+	var d screen
+	switch {
+	case len(conf.nodes) > 0:
+		d, err = newCluster(layout, conf, configs)
+	case len(configs) > 1:
+		d, err = newComparison(layout, conf, configs)
+	default:
+		var single *dashboard
+		single, err = newDashboard(layout, conf)
+		if single != nil {
+			single.config = configs[0]
+			d = single
+		}
 	}
-	dbConnMinIdleInt, err := strconv.Atoi(dbConnMinIdle)
 	if err != nil {
-		dbConnMinIdleInt = 0
-		//return 0, errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+		return err
 	}
-	pctDbConnActive := dbConnActiveInt / dbConnMaxInt * 100
-	g4.Percent = pctDbConnActive
-
-	//compute free space gauge
-	pctFreeSpace := new(big.Float).Mul(big.NewFloat(100), new(big.Float).Quo(freeSpace, totalSpace))
-	pctFreeSpaceStr := pctFreeSpace.String()
-	pctFreeSplit := strings.Split(pctFreeSpaceStr, ".")
-	pctFreeInt, _ := strconv.Atoi(pctFreeSplit[0])
-	g2.Percent = 100 - pctFreeInt
 
-	//compute free heap gauge
-	pctFreeHeapSpace := new(big.Float).Mul(big.NewFloat(100), new(big.Float).Quo(heapFreeSpace, heapMaxSpace))
-	pctFreeHeapSpaceStr := pctFreeHeapSpace.String()
-	pctFreeHeapSplit := strings.Split(pctFreeHeapSpaceStr, ".")
-	pctFreeHeapInt, _ := strconv.Atoi(pctFreeHeapSplit[0])
-	g3.Percent = pctFreeHeapInt
-
-	bc.Data = []float64{float64(dbConnActiveInt), float64(dbConnMaxInt), float64(dbConnIdleInt), float64(dbConnMinIdleInt)}
-
-	//list data
-	connMapsize := len(remoteConnMap)
-	var listRow = make([]string, connMapsize)
-	var bc2labels []string
-	var totalLease, totalMax, totalAvailable, totalPending int
-	mapCount := 0
-	var remoteBcData []float64
-	timeSecond := responseTime.Second()
-
-	helpers.LogRestFile.Debug("size of map before processing", len(rcPlotData))
-	if connMapsize > 0 {
-		helpers.LogRestFile.Trace("remote connection print out:", remoteConnMap)
-		for i := range remoteConnMap {
-			id := strings.Split(remoteConnMap[i].Name, "jfrt_http_connections")
-			uniqId := id[0] + string(remoteConnMap[i].Help[0])
-			bc2labels = append(bc2labels, uniqId)
-			listRow[mapCount] = remoteConnMap[i].Metric[0].Value + " " + remoteConnMap[i].Metric[0].Labels.Pool + " " + strings.ReplaceAll(remoteConnMap[i].Help, " Connections", "") + " " + uniqId
-			mapCount++
-
-			totalValue, err := strconv.Atoi(remoteConnMap[i].Metric[0].Value)
-			if err != nil {
-				totalValue = 0 //safety in case it can't convert
-				helpers.LogRestFile.Warn("Failed to convert number ", remoteConnMap[i].Metric[0].Value, " at ", helpers.Trace().Fn, " line ", helpers.Trace().Line)
-			}
-
-			//init the float for the map for plot
-			if strings.Contains(remoteConnMap[i].Name, "jfrt_http_connections_leased_total") {
-				if rcPlotData[uniqId] == nil {
-					var rcPlotDataRow = make([]float64, 60)
-					for i := 0; i < 60; i++ {
-						if i == timeSecond {
-							rcPlotDataRow[i] = float64(totalValue)
-						} else {
-							rcPlotDataRow[i] = 0
-						}
-					}
-					rcPlotData[uniqId] = rcPlotDataRow
-				} else {
-					// float row already exists, need to append/update
-					rcPlotDataRow := rcPlotData[uniqId]
-					rcPlotDataRow[timeSecond] = float64(totalValue)
-					for i := 0; i < interval; i++ {
-						if timeSecond+i < 60 {
-							rcPlotDataRow[timeSecond+i] = float64(totalValue)
-						} else {
-							for i := range rcPlotDataRow {
-								rcPlotDataRow[i] = 0
-							}
-							rcPlotData[uniqId] = rcPlotDataRow
-							helpers.LogRestFile.Info("reset graph")
-						}
-					}
-					rcPlotData[uniqId] = rcPlotDataRow
-				}
-			}
-
-			//append bar chart
-			remoteBcData = append(remoteBcData, float64(totalValue))
-
-			switch typeTotal := remoteConnMap[i].Help; typeTotal {
-			case "Leased Connections":
-				totalLease = totalLease + totalValue
+	if err := ui.Init(); err != nil {
+		fmt.Printf("failed to initialize termui: %v", err)
+		return err
+	}
+	defer ui.Close()
+	d.resize(ui.TerminalDimensions())
+	d.render()
 
-			case "Pending Connections":
-				totalPending = totalPending + totalValue
+	polls := make(chan poll)
+	done := make(chan struct{})
+	defer close(done)
+	controls := startPollers(pollSources(configs, conf), time.Second*time.Duration(interval), polls, done)
 
-			case "Max Connections":
-				totalMax = totalMax + totalValue
+	return runDashboard(d, ui.PollEvents(), polls, controls, func(clear bool) {
+		if clear {
+			ui.Clear()
+		}
+		d.render()
+	})
+}
 
-			case "Available Connections":
-				totalAvailable = totalAvailable + totalValue
+//getServerConfigs the config of every server id, checked to be up, or of the default server when there are none
+func getServerConfigs(serverIDs []string) ([]*config.ArtifactoryDetails, error) {
+	if len(serverIDs) == 0 {
+		serverConfig, err := helpers.GetConfig()
+		if err != nil {
+			return nil, err
+		}
+		return []*config.ArtifactoryDetails{serverConfig}, nil
+	}
+	if len(serverIDs) > len(serverColors) {
+		return nil, errors.New("At most " + strconv.Itoa(len(serverColors)) + " servers can be compared")
+	}
+	var configs []*config.ArtifactoryDetails
+	for i, serverID := range serverIDs {
+		for _, previous := range serverIDs[:i] {
+			if previous == serverID {
+				return nil, errors.New("Server id " + serverID + " is given more than once")
 			}
 		}
+		serverConfig, err := helpers.GetServerConfig(serverID)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, serverConfig)
 	}
+	return configs, nil
+}
 
-	bc2.Labels = bc2labels
-	bc2.Data = remoteBcData
-	l.Rows = listRow
-
-	//remote connection data
-	var rcPlotFinalData = make([][]float64, len(rcPlotData))
-	var rcCount int = 0
-	for i := range rcPlotData {
-		helpers.LogRestFile.Debug("rcPlot data:", rcPlotData[i])
-		helpers.LogRestFile.Debug("i:", i, " data size:", len(rcPlotData[i]))
-		if len(rcPlotData[i]) == 0 {
-			//skip
-			helpers.LogRestFile.Debug("Map is empty at this location:", i)
-		} else {
-
-			rcPlotFinalData[rcCount] = rcPlotData[i]
+//flagValues every value of a repeatable flag of command, see helpers.FlagValues. Falls back to the value the framework
+//kept when none is found on the command line
+func flagValues(c *components.Context, command components.Command, name string) []string {
+	values := commandLineValues(command, name)
+	if len(values) == 0 && c.GetStringFlagValue(name) != "" {
+		values = []string{c.GetStringFlagValue(name)}
+	}
+	return values
+}
 
+//commandLineValues the values of a flag of command given on the command line, none when it is left to its default
+func commandLineValues(command components.Command, name string) []string {
+	var boolFlags []string
+	for _, flag := range command.Flags {
+		if _, ok := flag.(components.BoolFlag); ok {
+			boolFlags = append(boolFlags, flag.GetName())
 		}
-		rcCount++
 	}
+	return helpers.FlagValues(os.Args, append([]string{command.Name}, command.Aliases...), name, boolFlags)
+}
 
-	//Db connection plot data
-	for i := 0; i < 60; i++ {
-		if i == int(timeSecond) {
-			//order: active, max, idle, minIdle
-			plotData[0][i] = float64(dbConnActiveInt)
-			plotData[1][i] = float64(0) //whats the point of plotting max
-			plotData[2][i] = float64(dbConnIdleInt)
-			plotData[3][i] = float64(dbConnMinIdleInt)
-			helpers.LogRestFile.Debug("current time:", i)
+//getNodes the HA nodes given with --node, or discovered with --discover-nodes through the first server
+func getNodes(c *components.Context, configs []*config.ArtifactoryDetails) ([]helpers.Node, error) {
+	values := flagValues(c, GetGraphCommand(), "node")
+	discover := c.GetBoolFlagValue("discover-nodes")
+	if len(values) == 0 && !discover {
+		return nil, nil
+	}
+	if len(values) > 0 && discover {
+		return nil, errors.New("Use either --node or --discover-nodes")
+	}
+	if len(configs) > 1 {
+		return nil, errors.New("HA nodes can only be polled for a single --server-id")
+	}
+	if discover {
+		return helpers.DiscoverNodes(configs[0])
+	}
+	var nodes []helpers.Node
+	for _, value := range values {
+		node, err := helpers.ParseNode(value)
+		if err != nil {
+			return nil, err
 		}
-
-		for i := 0; i < interval; i++ {
-			if timeSecond+i < 60 {
-				//order: active, max, idle, minIdle
-				plotData[0][timeSecond+i] = float64(dbConnActiveInt)
-				plotData[1][timeSecond+i] = float64(0) //whats the point of plotting max
-				plotData[2][timeSecond+i] = float64(dbConnIdleInt)
-				plotData[3][timeSecond+i] = float64(dbConnMinIdleInt)
-				helpers.LogRestFile.Debug("current time:", i)
+		for _, previous := range nodes {
+			if previous.ID == node.ID {
+				return nil, errors.New("Node id " + node.ID + " is given more than once")
 			}
 		}
+		nodes = append(nodes, node)
 	}
-	p1.Data = plotData
-	p2.DataLabels = []string{"hello"}
-
-	helpers.LogRestFile.Debug("size of plot rc:", len(rcPlotFinalData))
-	p2.Data = rcPlotFinalData
-
-	//total
-	p.Text = "Leased:" + strconv.Itoa(totalLease) + " Max:" + strconv.Itoa(totalMax) + " Available:" + strconv.Itoa(totalAvailable) + " Pending:" + strconv.Itoa(totalPending)
-	//metrics data
-	r.Text = "Count: " + strconv.Itoa(len(data)) + "\nHeap Proc: " + heapProc + "\nHeap Total: " + heapTotalSpace.String()
-
-	o.Text = "Current time: " + time.Now().Format("2006.01.02 15:04:05") + "\nLast updated: " + lastUpdate + " (" + strconv.Itoa(offset) + " seconds) Data Compute time:" + time.Now().Sub(responseTimeCompute).String() + "\nResponse time: " + time.Now().Sub(responseTime).String() + " Polling interval: every " + strconv.Itoa(interval) + " seconds\nServer url: " + config.ServerId
-
-	ui.Render(bc, bc2, g2, g3, g4, l, o, o2, p, p1, p2, q, r)
-	return offset, rcPlotData, nil
+	return nodes, nil
 }
 
 func Extend(slice []string, element string) []string {
@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"image"
+	"sort"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+//smallest useful panel, anything smaller is unreadable
+const (
+	minPanelWidth  = 12
+	minPanelHeight = 3
+	//maxStackedHeight tallest a panel gets when panels are stacked in a single column
+	maxStackedHeight = 8
+)
+
+//arrangeGrid fit the panels to the terminal. The layout rects are used as proportions of the layout's extent, so the
+//dashboard grows and shrinks with the terminal. When panels get too small the plots and sparklines are hidden first,
+//then the remaining panels are stacked in a single column, dropping those that do not fit. Returns the shown panels
+func arrangeGrid(grid *ui.Grid, panels []*panel, area image.Rectangle) []*panel {
+	grid.SetRect(area.Min.X, area.Min.Y, area.Max.X, area.Max.Y)
+	grid.Items = nil
+	width, height := area.Dx(), area.Dy()
+
+	if fitsProportional(panels, width, height) {
+		setProportional(grid, panels)
+		return panels
+	}
+	var textPanels []*panel
+	for _, pn := range panels {
+		if pn.spec.Type != "plot" && pn.spec.Type != "sparkline" {
+			textPanels = append(textPanels, pn)
+		}
+	}
+	if len(textPanels) > 0 && fitsProportional(textPanels, width, height) {
+		setProportional(grid, textPanels)
+		return textPanels
+	}
+	if len(textPanels) == 0 {
+		textPanels = panels
+	}
+	return setStacked(grid, textPanels, height)
+}
+
+//extent bottom right corner of the layout
+func extent(panels []*panel) (int, int) {
+	var x, y int
+	for _, pn := range panels {
+		if pn.spec.Rect[2] > x {
+			x = pn.spec.Rect[2]
+		}
+		if pn.spec.Rect[3] > y {
+			y = pn.spec.Rect[3]
+		}
+	}
+	return x, y
+}
+
+func fitsProportional(panels []*panel, width, height int) bool {
+	extentX, extentY := extent(panels)
+	for _, pn := range panels {
+		rect := pn.spec.Rect
+		if (rect[2]-rect[0])*(width+1)/extentX < minPanelWidth || (rect[3]-rect[1])*(height+1)/extentY < minPanelHeight {
+			return false
+		}
+	}
+	return true
+}
+
+func setProportional(grid *ui.Grid, panels []*panel) {
+	extentX, extentY := extent(panels)
+	for _, pn := range panels {
+		rect := pn.spec.Rect
+		grid.Items = append(grid.Items, &ui.GridItem{
+			XRatio:      float64(rect[0]) / float64(extentX),
+			YRatio:      float64(rect[1]) / float64(extentY),
+			WidthRatio:  float64(rect[2]-rect[0]) / float64(extentX),
+			HeightRatio: float64(rect[3]-rect[1]) / float64(extentY),
+			Entry:       pn.widget,
+			IsLeaf:      true,
+		})
+	}
+}
+
+//setStacked one full width column in reading order (top to bottom, left to right)
+func setStacked(grid *ui.Grid, panels []*panel, height int) []*panel {
+	ordered := append([]*panel{}, panels...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].spec.Rect[1] != ordered[j].spec.Rect[1] {
+			return ordered[i].spec.Rect[1] < ordered[j].spec.Rect[1]
+		}
+		return ordered[i].spec.Rect[0] < ordered[j].spec.Rect[0]
+	})
+
+	var shown []*panel
+	y := 0
+	for _, pn := range ordered {
+		h := pn.spec.Rect[3] - pn.spec.Rect[1]
+		if h > maxStackedHeight {
+			h = maxStackedHeight
+		}
+		if h < minPanelHeight {
+			h = minPanelHeight
+		}
+		if y+h > height {
+			break
+		}
+		grid.Items = append(grid.Items, &ui.GridItem{
+			//half a cell so the grid's truncation lands on the intended row
+			YRatio:      (float64(y) + 0.5) / float64(height+1),
+			WidthRatio:  1,
+			HeightRatio: (float64(h) + 0.5) / float64(height+1),
+			Entry:       pn.widget,
+			IsLeaf:      true,
+		})
+		shown = append(shown, pn)
+		y += h
+	}
+	return shown
+}
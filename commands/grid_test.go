@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"image"
+	"testing"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func defaultPanels(t *testing.T) []*panel {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	var panels []*panel
+	for _, spec := range layout.pages()[0].Panels {
+		pn, err := newPanel(spec)
+		assert.NoError(t, err)
+		panels = append(panels, pn)
+	}
+	return panels
+}
+
+func drawGrid(grid *ui.Grid) {
+	grid.Draw(ui.NewBuffer(grid.GetRect()))
+}
+
+func TestArrangeGridProportional(t *testing.T) {
+	panels := defaultPanels(t)
+	grid := ui.NewGrid()
+
+	shown := arrangeGrid(grid, panels, image.Rect(0, 0, 146, 56))
+	assert.Len(t, shown, len(panels))
+	drawGrid(grid)
+	assert.Equal(t, image.Rect(0, 0, 77, 6), panels[0].widget.GetRect())
+
+	//twice the terminal, twice the panels
+	shown = arrangeGrid(grid, panels, image.Rect(0, 0, 292, 112))
+	assert.Len(t, shown, len(panels))
+	drawGrid(grid)
+	assert.Equal(t, image.Rect(0, 0, 154, 12), panels[0].widget.GetRect())
+}
+
+func TestArrangeGridHidesPlots(t *testing.T) {
+	specs := []PanelSpec{
+		{Type: "gauge", Rect: []int{0, 0, 40, 3}, Source: "cpu"},
+		{Type: "list", Rect: []int{0, 3, 40, 20}, Source: "remote_pools"},
+		{Type: "plot", Rect: []int{40, 0, 55, 20}, Source: "db_pool"},
+	}
+	var panels []*panel
+	for _, spec := range specs {
+		pn, err := newPanel(spec)
+		assert.NoError(t, err)
+		panels = append(panels, pn)
+	}
+	grid := ui.NewGrid()
+	shown := arrangeGrid(grid, panels, image.Rect(0, 0, 55, 20))
+	assert.Len(t, shown, 3)
+
+	//the plot would be 11 columns wide
+	shown = arrangeGrid(grid, panels, image.Rect(0, 0, 40, 20))
+	assert.Equal(t, panels[:2], shown)
+	drawGrid(grid)
+	assert.Equal(t, image.Rect(0, 0, 40, 3), panels[0].widget.GetRect())
+}
+
+func TestArrangeGridStacked(t *testing.T) {
+	panels := defaultPanels(t)
+	grid := ui.NewGrid()
+
+	shown := arrangeGrid(grid, panels, image.Rect(0, 0, 80, 24))
+	var titles []string
+	for _, pn := range shown {
+		titles = append(titles, pn.spec.Title)
+	}
+	assert.Equal(t, []string{"Meta statistics", "Total Remote Conns", "CPU", "Number of Metrics", "Current Used Storage"}, titles)
+	drawGrid(grid)
+	assert.Equal(t, image.Rect(0, 0, 80, 6), shown[0].widget.GetRect())
+	assert.Equal(t, image.Rect(0, 6, 80, 11), shown[1].widget.GetRect())
+	assert.Equal(t, image.Rect(0, 21, 80, 24), shown[4].widget.GetRect())
+}
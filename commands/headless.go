@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//graphHeadless poll like the dashboard does and print its model instead of drawing it, starting right away. Several
+//servers or HA nodes are polled concurrently, their lines interleaved
+func graphHeadless(configs []*config.ArtifactoryDetails, conf *GraphConfiguration) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	sources := pollSources(configs, conf)
+	var sourceConfigs []*config.ArtifactoryDetails
+	for _, source := range sources {
+		sourceConfigs = append(sourceConfigs, source.config)
+	}
+	polls := make(chan poll)
+	done := make(chan struct{})
+	defer close(done)
+	for _, control := range startPollers(sources, time.Second*time.Duration(conf.interval), polls, done) {
+		control <- pollerControl{command: pollNow}
+	}
+
+	return runHeadless(os.Stdout, os.Stderr, sourceConfigs, conf, polls, interrupt)
+}
+
+//runHeadless write one model per poll, as a line or a JSON object, until conf.polls polls of every server or an
+//interrupt. configs holds the config of each source, polls of HA nodes are named after conf.nodes. A failed poll is
+//reported on errOut and counts as a poll, the other sources keep going
+func runHeadless(out io.Writer, errOut io.Writer, configs []*config.ArtifactoryDetails, conf *GraphConfiguration, polls <-chan poll, interrupt <-chan os.Signal) error {
+	rates := make([]*helpers.RateTracker, len(configs))
+	for i := range rates {
+		rates[i] = helpers.NewRateTracker(time.Minute)
+	}
+	for n := 0; conf.polls == 0 || n < conf.polls*len(configs); n++ {
+		var p poll
+		select {
+		case p = <-polls:
+		case <-interrupt:
+			return nil
+		}
+		var node string
+		if len(conf.nodes) > 0 {
+			node = conf.nodes[p.server].ID
+		}
+		if p.err != nil {
+			helpers.LogRestFile.Warn(configs[p.server].ServerId, " ", node, " poll failed: ", p.err)
+			source := configs[p.server].ServerId
+			if node != "" {
+				source += " node " + node
+			}
+			fmt.Fprintln(errOut, p.time.Format(time.RFC3339)+" "+source+": poll failed: "+p.err.Error())
+			continue
+		}
+		rates[p.server].Observe(p.data, p.time)
+		model := newDashboardModel(&pollResult{
+			node:   node,
+			config: configs[p.server],
+			conf:   conf,
+			data:   p.data,
+			pools:  helpers.GetConnectionPools(p.data),
+			rates:  rates[p.server],
+			time:   p.time,
+		})
+		var line []byte
+		if conf.format == "json" {
+			var err error
+			line, err = json.Marshal(model)
+			if err != nil {
+				return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
+			}
+		} else {
+			line = []byte(model.line())
+		}
+		if _, err := out.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
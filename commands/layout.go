@@ -0,0 +1,446 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+	helpers "github.com/jfrog/frogvision/utils"
+	"gopkg.in/yaml.v2"
+)
+
+//Layout dashboard description, loaded from --layout or defaultLayout. Either a single page of panels or several pages
+type Layout struct {
+	Panels []PanelSpec `yaml:"panels,omitempty"`
+	Pages  []PageSpec  `yaml:"pages,omitempty"`
+}
+
+//PageSpec one tab of the dashboard
+type PageSpec struct {
+	Name   string      `yaml:"name"`
+	Panels []PanelSpec `yaml:"panels"`
+}
+
+//maxPages pages are selected with the number keys 1 to 9
+const maxPages = 9
+
+//PanelSpec one widget of the dashboard. A panel binds either to a metric selector or to one of the built-in sources
+type PanelSpec struct {
+	Title string `yaml:"title"`
+	//Type gauge, plot, barchart, list, paragraph or sparkline
+	Type string `yaml:"type"`
+	//Rect x1, y1, x2, y2 in cells at the reference size, the dashboard is scaled to the terminal proportionally
+	Rect []int `yaml:"rect"`
+	//Metric selector the panel binds to, one value per matching series
+	Metric string `yaml:"metric,omitempty"`
+	//Source built-in derived value, see panelSources
+	Source string `yaml:"source,omitempty"`
+	//Max gauge only, a number or a selector whose first value is 100%
+	Max string `yaml:"max,omitempty"`
+	//Invert gauge only, show 100 - percent, e.g. used space from free space
+	Invert bool `yaml:"invert,omitempty"`
+	//Label label name used to name series, e.g. pool. Defaults to the full label set
+	Label string `yaml:"label,omitempty"`
+	//Series only show values with these names, in this order
+	Series []string `yaml:"series,omitempty"`
+	//Format number, bytes, percent, seconds or a printf verb such as %.2f
+	Format string `yaml:"format,omitempty"`
+	//Colors widget colors in series order: black, red, green, yellow, blue, magenta, cyan, white
+	Colors   []string `yaml:"colors,omitempty"`
+	BarWidth int      `yaml:"bar_width,omitempty"`
+	//Warn and Critical thresholds on the gauge percent, or on the highest value of other panels. Crossing one turns
+	//the panel yellow or red and is reported in the status line
+	Warn     *float64 `yaml:"warn,omitempty"`
+	Critical *float64 `yaml:"critical,omitempty"`
+	//Legend list only, show the values in the line colors of a plot bound to the same metric and colors
+	Legend bool `yaml:"legend,omitempty"`
+}
+
+var panelTypes = []string{"gauge", "plot", "barchart", "list", "paragraph", "sparkline"}
+
+//defaultLayout the built-in dashboard
+const defaultLayout = `pages:
+  - name: Overview
+    panels:
+      - title: Meta statistics
+        type: paragraph
+        rect: [0, 0, 77, 6]
+        source: meta
+      - title: Total Remote Conns
+        type: paragraph
+        rect: [0, 6, 25, 11]
+        source: pool_totals
+      - title: CPU
+        type: paragraph
+        rect: [26, 6, 51, 11]
+        source: cpu
+      - title: Number of Metrics
+        type: paragraph
+        rect: [52, 6, 77, 11]
+        source: metrics_count
+      - title: Current Used Storage
+        type: gauge
+        rect: [0, 11, 36, 14]
+        metric: app_disk_free_bytes
+        max: app_disk_total_bytes
+        invert: true
+        warn: 80
+        critical: 90
+      - title: Current Used Heap
+        type: gauge
+        rect: [0, 14, 36, 17]
+        source: heap
+        series: [Used]
+        max: jfrt_runtime_heap_maxmemory_bytes
+        format: bytes
+        warn: 80
+        critical: 90
+      - title: Active DB connections
+        type: gauge
+        rect: [0, 17, 36, 20]
+        metric: jfrt_db_connections_active_total
+        max: jfrt_db_connections_max_active_total
+        warn: 75
+        critical: 90
+      - title: DB Connections
+        type: barchart
+        rect: [0, 20, 36, 34]
+        source: db_pool
+        bar_width: 5
+        colors: [black, green, blue, red]
+      - title: Remote Connections List
+        type: list
+        rect: [37, 11, 77, 34]
+        source: remote_pools
+        colors: [yellow]
+      - title: Remote Connections Barchart (leased per pool)
+        type: barchart
+        rect: [0, 34, 77, 45]
+        source: remote_pools
+        bar_width: 3
+      - title: Garbage Collection statistics
+        type: paragraph
+        rect: [0, 45, 77, 51]
+        source: gc
+      - title: Latency percentiles (p50 / p95 / p99)
+        type: paragraph
+        rect: [0, 51, 77, 56]
+        source: latency
+      - title: DB Connection Chart
+        type: plot
+        rect: [78, 0, 146, 28]
+        source: db_pool
+        series: [Active, Idle, MinIdle]
+        colors: [black, blue, red]
+      - title: Remote Connections Chart
+        type: plot
+        rect: [78, 28, 146, 56]
+        source: remote_pools
+  - name: Database
+    panels:
+      - title: Active DB connections
+        type: gauge
+        rect: [0, 0, 73, 3]
+        metric: jfrt_db_connections_active_total
+        max: jfrt_db_connections_max_active_total
+        warn: 75
+        critical: 90
+      - title: Idle DB connections
+        type: gauge
+        rect: [73, 0, 146, 3]
+        metric: jfrt_db_connections_idle_total
+        max: jfrt_db_connections_max_active_total
+      - title: DB Connections
+        type: barchart
+        rect: [0, 3, 40, 30]
+        source: db_pool
+        bar_width: 7
+        colors: [black, green, blue, red]
+      - title: DB metrics
+        type: list
+        rect: [40, 3, 146, 30]
+        metric: jfrt_db_.*
+      - title: DB Connection Chart
+        type: plot
+        rect: [0, 30, 146, 56]
+        source: db_pool
+        series: [Active, Idle, MinIdle]
+        colors: [black, blue, red]
+  - name: Remote Connections
+    panels:
+      - title: Total Remote Conns
+        type: paragraph
+        rect: [0, 0, 60, 3]
+        source: pool_totals
+      - title: Remote Connections List
+        type: list
+        rect: [0, 3, 60, 56]
+        source: remote_pools
+        colors: [yellow]
+      - title: Leased per pool
+        type: barchart
+        rect: [60, 0, 146, 14]
+        source: remote_pools
+        bar_width: 3
+      - title: Pending per pool
+        type: barchart
+        rect: [60, 14, 146, 28]
+        metric: jfrt_http_connections_pending_total
+        label: pool
+        bar_width: 3
+        colors: [yellow]
+      - title: Remote Connections Chart
+        type: plot
+        rect: [60, 28, 146, 56]
+        source: remote_pools
+  - name: Storage & GC
+    panels:
+      - title: Current Used Storage
+        type: gauge
+        rect: [0, 0, 146, 3]
+        metric: app_disk_free_bytes
+        max: app_disk_total_bytes
+        invert: true
+        warn: 80
+        critical: 90
+      - title: Disk
+        type: paragraph
+        rect: [0, 3, 40, 9]
+        metric: app_disk_.*
+        format: bytes
+      - title: Disk forecast
+        type: paragraph
+        rect: [40, 3, 90, 9]
+        source: disk_forecast
+      - title: Garbage Collection statistics
+        type: paragraph
+        rect: [90, 3, 146, 9]
+        source: gc
+      - title: Free disk space
+        type: plot
+        rect: [0, 9, 146, 30]
+        metric: app_disk_free_bytes
+      - title: Garbage Collection history
+        type: list
+        rect: [0, 30, 100, 56]
+        source: gc_history
+      - title: Reclaimed per second by run
+        type: barchart
+        rect: [100, 30, 146, 56]
+        source: gc_history
+        format: bytes
+        bar_width: 7
+  - name: JVM
+    panels:
+      - title: Current Used Heap
+        type: gauge
+        rect: [0, 0, 73, 6]
+        source: heap
+        series: [Used]
+        max: jfrt_runtime_heap_maxmemory_bytes
+        format: bytes
+        warn: 80
+        critical: 90
+      - title: CPU
+        type: paragraph
+        rect: [73, 0, 146, 6]
+        source: cpu
+      - title: Heap
+        type: paragraph
+        rect: [0, 6, 73, 14]
+        source: heap
+      - title: CPU utilization
+        type: sparkline
+        rect: [73, 6, 146, 14]
+        source: cpu
+        format: percent
+      - title: Heap Chart (used / committed / max)
+        type: plot
+        rect: [0, 14, 116, 40]
+        source: heap
+      - title: Heap
+        type: list
+        rect: [116, 14, 146, 40]
+        source: heap
+        format: bytes
+        legend: true
+      - title: Latency percentiles (p50 / p95 / p99)
+        type: paragraph
+        rect: [0, 40, 146, 56]
+        source: latency
+  - name: All Metrics
+    panels:
+      - title: All Metrics
+        type: list
+        rect: [0, 0, 146, 56]
+        source: all_metrics
+`
+
+//metricsLayout the graph --metric view: a plot per selector, one line per matching series, each with a legend
+func metricsLayout(selectors []string) (*Layout, error) {
+	const width, legendX, metaHeight, height = 146, 110, 6, 56
+	panels := []PanelSpec{{Title: "Meta statistics", Type: "paragraph", Rect: []int{0, 0, width, metaHeight}, Source: "meta"}}
+	rows := (height - metaHeight) / len(selectors)
+	for i, selector := range selectors {
+		y1, y2 := metaHeight+i*rows, metaHeight+(i+1)*rows
+		if i == len(selectors)-1 {
+			y2 = height
+		}
+		panels = append(panels,
+			PanelSpec{Title: selector, Type: "plot", Rect: []int{0, y1, legendX, y2}, Metric: selector},
+			PanelSpec{Title: "Legend", Type: "list", Rect: []int{legendX, y1, width, y2}, Metric: selector, Legend: true})
+	}
+	layout := &Layout{Pages: []PageSpec{{Name: "Metrics", Panels: panels}}}
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+//loadLayout read a layout file, or the built-in default when path is empty
+func loadLayout(path string) (*Layout, error) {
+	content := []byte(defaultLayout)
+	if path != "" {
+		var err error
+		content, err = ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errors.New("Failed to read layout " + path + ": " + err.Error())
+		}
+	}
+	layout := new(Layout)
+	if err := yaml.UnmarshalStrict(content, layout); err != nil {
+		return nil, errors.New("Invalid layout " + path + ": " + err.Error())
+	}
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+	return layout, nil
+}
+
+//pages the layout pages, a layout with only panels is a single page
+func (layout *Layout) pages() []PageSpec {
+	if len(layout.Pages) > 0 {
+		return layout.Pages
+	}
+	return []PageSpec{{Name: "Dashboard", Panels: layout.Panels}}
+}
+
+func (layout *Layout) validate() error {
+	if len(layout.Panels) > 0 && len(layout.Pages) > 0 {
+		return errors.New("Layout has both panels and pages, put the panels in a page")
+	}
+	if len(layout.Pages) > maxPages {
+		return errors.New("Layout has " + strconv.Itoa(len(layout.Pages)) + " pages, at most " + strconv.Itoa(maxPages) + " are supported")
+	}
+	for i, page := range layout.pages() {
+		if page.Name == "" {
+			return errors.New("page " + strconv.Itoa(i+1) + " has no name")
+		}
+		if len(page.Panels) == 0 {
+			return errors.New("Layout page " + page.Name + " has no panels")
+		}
+		if err := validatePanels(page.Panels); err != nil {
+			if len(layout.Pages) > 0 {
+				return errors.New("page " + page.Name + ": " + err.Error())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePanels(panels []PanelSpec) error {
+	for i, spec := range panels {
+		name := "panel " + strconv.Itoa(i+1) + " (" + spec.Title + ")"
+		if !containsFold(panelTypes, spec.Type) {
+			return errors.New(name + ": unknown type " + spec.Type + ", expected one of: " + strings.Join(panelTypes, ", "))
+		}
+		if len(spec.Rect) != 4 || spec.Rect[0] < 0 || spec.Rect[1] < 0 || spec.Rect[2] <= spec.Rect[0] || spec.Rect[3] <= spec.Rect[1] {
+			return errors.New(name + ": rect must be [x1, y1, x2, y2] with 0 <= x1 < x2 and 0 <= y1 < y2")
+		}
+		if (spec.Metric == "") == (spec.Source == "") {
+			return errors.New(name + ": set exactly one of metric or source")
+		}
+		if spec.Metric != "" {
+			if _, err := helpers.ParseSelector(spec.Metric); err != nil {
+				return errors.New(name + ": " + err.Error())
+			}
+		}
+		if spec.Source != "" {
+			if _, ok := panelSources[spec.Source]; !ok {
+				return errors.New(name + ": unknown source " + spec.Source + ", expected one of: " + strings.Join(sourceNames(), ", "))
+			}
+		}
+		if _, err := strconv.ParseFloat(spec.Max, 64); spec.Max != "" && err != nil {
+			if _, err := helpers.ParseSelector(spec.Max); err != nil {
+				return errors.New(name + ": max: " + err.Error())
+			}
+		}
+		if spec.Legend && !strings.EqualFold(spec.Type, "list") {
+			return errors.New(name + ": legend is only supported on lists")
+		}
+		if spec.Warn != nil && spec.Critical != nil && *spec.Warn > *spec.Critical {
+			return errors.New(name + ": warn must not be above critical")
+		}
+		for _, color := range spec.Colors {
+			if _, ok := colorNames[strings.ToLower(color)]; !ok {
+				return errors.New(name + ": unknown color " + color)
+			}
+		}
+	}
+	return nil
+}
+
+var colorNames = map[string]ui.Color{
+	"black":   ui.ColorBlack,
+	"red":     ui.ColorRed,
+	"green":   ui.ColorGreen,
+	"yellow":  ui.ColorYellow,
+	"blue":    ui.ColorBlue,
+	"magenta": ui.ColorMagenta,
+	"cyan":    ui.ColorCyan,
+	"white":   ui.ColorWhite,
+}
+
+//colorName the layout name of a color
+func colorName(color ui.Color) string {
+	for name, c := range colorNames {
+		if c == color {
+			return name
+		}
+	}
+	return "white"
+}
+
+//colors panel colors, falling back to fallback when none are configured
+func (spec PanelSpec) colors(fallback ...ui.Color) []ui.Color {
+	if len(spec.Colors) == 0 {
+		return fallback
+	}
+	var colors []ui.Color
+	for _, color := range spec.Colors {
+		colors = append(colors, colorNames[strings.ToLower(color)])
+	}
+	return colors
+}
+
+//formatValue render a value with the panel format
+func (spec PanelSpec) formatValue(value float64) string {
+	switch spec.Format {
+	case "", "number":
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case "bytes":
+		return helpers.ByteCountDecimal(int64(value))
+	case "percent":
+		return strconv.FormatFloat(value, 'f', 1, 64) + "%"
+	case "seconds":
+		return strconv.FormatFloat(value, 'f', 2, 64) + "s"
+	}
+	if strings.Contains(spec.Format, "%") {
+		return fmt.Sprintf(spec.Format, value)
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
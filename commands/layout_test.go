@@ -0,0 +1,215 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const dashboardText = `# HELP app_disk_free_bytes Free Space
+# TYPE app_disk_free_bytes gauge
+app_disk_free_bytes 375 1607287853275
+# HELP app_disk_total_bytes Total Space
+# TYPE app_disk_total_bytes gauge
+app_disk_total_bytes 1000 1607287853275
+# HELP jfrt_db_connections_active_total Total Active Connections
+# TYPE jfrt_db_connections_active_total gauge
+jfrt_db_connections_active_total 4 1607287853275
+# HELP jfrt_db_connections_max_active_total Total Max Active Connections
+# TYPE jfrt_db_connections_max_active_total gauge
+jfrt_db_connections_max_active_total 100 1607287853275
+# HELP jfrt_http_connections_leased_total Leased Connections
+# TYPE jfrt_http_connections_leased_total gauge
+jfrt_http_connections_leased_total{max="50",pool="npm-remote"} 2 1607287853275
+jfrt_http_connections_leased_total{max="20",pool="maven-remote"} 5 1607287853275
+`
+
+func parseDashboardMetrics(t *testing.T, text string) []helpers.Data {
+	jsonText, err := helpers.MetricsTextToJSON([]byte(text), false)
+	assert.NoError(t, err)
+	data, err := helpers.ParseMetricsJSON(jsonText)
+	assert.NoError(t, err)
+	return data
+}
+
+func testGraphConfiguration() *GraphConfiguration {
+	return &GraphConfiguration{interval: 1, retention: 15 * time.Minute, window: time.Minute}
+}
+
+func testPollResult(t *testing.T, text string) *pollResult {
+	data := parseDashboardMetrics(t, text)
+	return &pollResult{
+		config: &config.ArtifactoryDetails{ServerId: "test"},
+		conf:   testGraphConfiguration(),
+		data:   data,
+		pools:  helpers.GetConnectionPools(data),
+		rates:  helpers.NewRateTracker(time.Minute),
+		time:   time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC),
+	}
+}
+
+func TestDefaultLayout(t *testing.T) {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	assert.Empty(t, layout.Panels)
+	var names []string
+	for _, page := range layout.pages() {
+		names = append(names, page.Name)
+	}
+	assert.Equal(t, []string{"Overview", "Database", "Remote Connections", "Storage & GC", "JVM", "All Metrics"}, names)
+	assert.Len(t, layout.Pages[0].Panels, 14)
+	_, err = newDashboard(layout, testGraphConfiguration())
+	assert.NoError(t, err)
+}
+
+func TestLoadLayoutErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layout")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := map[string]string{
+		"panels: []": "no panels",
+		"panels:\n  - {title: a, type: pie, rect: [0, 0, 10, 10], source: cpu}":                         "unknown type pie",
+		"panels:\n  - {title: a, type: gauge, rect: [0, 0, 10], source: cpu}":                           "rect must be",
+		"panels:\n  - {title: a, type: gauge, rect: [0, 0, 10, 10]}":                                    "exactly one of metric or source",
+		"panels:\n  - {title: a, type: gauge, rect: [0, 0, 10, 10], source: disk}":                      "unknown source disk",
+		"panels:\n  - {title: a, type: gauge, rect: [0, 0, 10, 10], metric: 'x{a=\"b\"'}":               "unterminated",
+		"panels:\n  - {title: a, type: list, rect: [0, 0, 10, 10], source: cpu, colors: [pink]}":        "unknown color pink",
+		"pages:\n  - {name: a, panels: [{title: a, type: pie, rect: [0, 0, 10, 10], source: cpu}]}":     "page a: panel 1 (a): unknown type pie",
+		"pages:\n  - {panels: [{title: a, type: list, rect: [0, 0, 10, 10], source: cpu}]}":             "page 1 has no name",
+		"panels: [{title: a, type: list, rect: [0, 0, 10, 10], source: cpu}]\npages: [{name: a}]":       "both panels and pages",
+		"panels:\n  - {title: a, typ: list}":                                                            "field typ not found",
+		"panels:\n  - {title: a, type: gauge, rect: [0, 0, 10, 10], source: cpu, warn: 9, critical: 5}": "warn must not be above critical",
+		"panels:\n  - {title: a, type: plot, rect: [0, 0, 10, 10], source: cpu, legend: true}":          "legend is only supported on lists",
+	}
+	for content, message := range tests {
+		path := filepath.Join(dir, "layout.yaml")
+		assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+		_, err := loadLayout(path)
+		if assert.Error(t, err, content) {
+			assert.Contains(t, err.Error(), message)
+		}
+	}
+	_, err = loadLayout(filepath.Join(dir, "missing.yaml"))
+	assert.Error(t, err)
+}
+
+func TestPanelUpdate(t *testing.T) {
+	result := testPollResult(t, dashboardText)
+	store := helpers.NewSeriesStore(time.Minute, time.Second)
+
+	storage, err := newPanel(PanelSpec{Type: "gauge", Rect: []int{0, 0, 10, 3}, Metric: "app_disk_free_bytes", Max: "app_disk_total_bytes", Invert: true})
+	assert.NoError(t, err)
+	storage.update(result, store)
+	assert.Equal(t, 63, storage.widget.(*widgets.Gauge).Percent)
+
+	db, err := newPanel(PanelSpec{Type: "gauge", Rect: []int{0, 0, 10, 3}, Metric: "jfrt_db_connections_active_total", Max: "50", Format: "number"})
+	assert.NoError(t, err)
+	db.update(result, store)
+	assert.Equal(t, 8, db.widget.(*widgets.Gauge).Percent)
+	assert.Equal(t, "8% 4 / 50", db.widget.(*widgets.Gauge).Label)
+
+	leased, err := newPanel(PanelSpec{Type: "barchart", Rect: []int{0, 0, 10, 10}, Metric: "jfrt_http_connections_leased_total", Label: "pool", Series: []string{"npm-remote", "maven-remote"}})
+	assert.NoError(t, err)
+	leased.update(result, store)
+	assert.Equal(t, []string{"npm-remote", "maven-remote"}, leased.widget.(*widgets.BarChart).Labels)
+	assert.Equal(t, []float64{2, 5}, leased.widget.(*widgets.BarChart).Data)
+
+	pools, err := newPanel(PanelSpec{Type: "list", Rect: []int{0, 0, 10, 10}, Source: "remote_pools"})
+	assert.NoError(t, err)
+	pools.update(result, store)
+	assert.Equal(t, []string{"[0] maven-remote Leased:5 Pending:0 Available:0 Max:20", "[1] npm-remote Leased:2 Pending:0 Available:0 Max:50"}, pools.widget.(*widgets.List).Rows)
+
+	plot, err := newPanel(PanelSpec{Title: "DB", Type: "plot", Rect: []int{0, 0, 15, 10}, Source: "db_pool", Series: []string{"Active", "Max"}})
+	assert.NoError(t, err)
+	plot.update(result, store)
+	plot.plot(plotView{store: store, end: result.time, width: time.Minute})
+	w := plot.widget.(*widgets.Plot)
+	assert.Equal(t, "DB (last 1m0s)", w.Title)
+	assert.Len(t, w.Data, 2)
+	//15 columns minus borders and axes
+	assert.Equal(t, []float64{0, 0, 0, 0, 0, 0, 0, 4}, w.Data[0])
+	assert.Equal(t, float64(100), w.Data[1][7])
+	last, ok := store.Last("db_pool/Active")
+	assert.True(t, ok)
+	assert.Equal(t, float64(4), last.Value)
+
+	empty, err := newPanel(PanelSpec{Type: "sparkline", Rect: []int{0, 0, 10, 10}, Metric: "missing"})
+	assert.NoError(t, err)
+	empty.update(result, store)
+	empty.plot(plotView{store: store, end: result.time, width: time.Minute})
+	assert.Len(t, empty.widget.(*widgets.SparklineGroup).Sparklines, 1)
+}
+
+func TestMetricsLayout(t *testing.T) {
+	layout, err := metricsLayout([]string{"jfrt_http_connections_leased_total", "missing", "app_disk_.*"})
+	assert.NoError(t, err)
+	panels := layout.pages()[0].Panels
+	assert.Len(t, panels, 7)
+	assert.Equal(t, []int{0, 6, 110, 22}, panels[1].Rect)
+	assert.Equal(t, []int{110, 6, 146, 22}, panels[2].Rect)
+	assert.Equal(t, []int{0, 38, 110, 56}, panels[5].Rect)
+
+	d, err := newDashboard(layout, testGraphConfiguration())
+	assert.NoError(t, err)
+	d.resize(146, 59)
+	result := testPollResult(t, dashboardText)
+	result.conf = d.conf
+	d.update(result)
+	plot := d.pages[0].panels[1].widget.(*widgets.Plot)
+	assert.Len(t, plot.Data, 2)
+	assert.Equal(t, "jfrt_http_connections_leased_total (last 1m0s)", plot.Title)
+	legend := d.pages[0].panels[2].widget.(*widgets.List)
+	assert.Equal(t, []string{
+		`[■ jfrt_http_connections_leased_total{max="50",pool="npm-remote"}](fg:red) 2`,
+		`[■ jfrt_http_connections_leased_total{max="20",pool="maven-remote"}](fg:green) 5`,
+	}, legend.Rows)
+	assert.Equal(t, []string{"No series match missing"}, d.pages[0].panels[4].widget.(*widgets.List).Rows)
+
+	_, err = metricsLayout([]string{`x{a="b"`})
+	assert.Error(t, err)
+}
+
+func TestLayoutOverrideThresholds(t *testing.T) {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	assert.NoError(t, layout.overrideThresholds([]string{"Current Used Storage=60,95", "active db connections=,50"}))
+	for _, page := range layout.pages() {
+		for _, spec := range page.Panels {
+			switch spec.Title {
+			case "Current Used Storage":
+				assert.Equal(t, 60.0, *spec.Warn, page.Name)
+				assert.Equal(t, 95.0, *spec.Critical, page.Name)
+			case "Active DB connections":
+				assert.Nil(t, spec.Warn, page.Name)
+				assert.Equal(t, 50.0, *spec.Critical, page.Name)
+			case "Current Used Heap":
+				assert.Equal(t, 80.0, *spec.Warn, page.Name)
+			}
+		}
+	}
+
+	tests := map[string]string{
+		"Current Used Storage":         "expected title=warn,critical",
+		"=80,90":                       "expected title=warn,critical",
+		"Current Used Storage=80":      "expected title=warn,critical",
+		"Current Used Storage=high,90": "high is not a number",
+		"Missing panel=80,90":          "no panel titled Missing panel",
+		"Current Used Storage=95,90":   "warn must not be above critical",
+	}
+	for override, message := range tests {
+		layout, err := loadLayout("")
+		assert.NoError(t, err)
+		err = layout.overrideThresholds([]string{override})
+		if assert.Error(t, err, override) {
+			assert.Contains(t, err.Error(), message, override)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	helpers "github.com/jfrog/frogvision/utils"
+)
+
+type ListConfiguration struct {
+	group     bool
+	tree      bool
+	typeNames []string
+}
+
+//familyLine one annotated family: type, series count, UPDATED age and HELP
+func familyLine(d helpers.Data, now time.Time) string {
+	age := "-"
+	if updated, ok := d.UpdatedTime(); ok {
+		age = helpers.FormatAge(now.Sub(updated)) + " ago"
+	}
+	return strings.ToLower(d.Type) + "\t" + strconv.Itoa(len(d.Metric)) + " series\t" + age + "\t" + d.Help
+}
+
+//metricsList write every family with its metadata, optionally filtered by type and grouped by namespace or as a tree
+func metricsList(w io.Writer, metricsData []helpers.Data, conf *ListConfiguration) error {
+	var families []helpers.Data
+	for i := range metricsData {
+		if len(conf.typeNames) > 0 && !containsFold(conf.typeNames, metricsData[i].Type) {
+			continue
+		}
+		families = append(families, metricsData[i])
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+
+	fmt.Fprintln(w, "Found", len(families), "metrics")
+	now := time.Now()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	if !conf.group && !conf.tree {
+		for i := range families {
+			fmt.Fprintln(tw, families[i].Name+"\t"+familyLine(families[i], now))
+		}
+		return tw.Flush()
+	}
+
+	namespaces := append(append([]string{}, helpers.MetricNamespaces...), "other")
+	grouped := make(map[string][]helpers.Data)
+	for i := range families {
+		ns := helpers.MetricNamespace(families[i].Name)
+		grouped[ns] = append(grouped[ns], families[i])
+	}
+	for _, ns := range namespaces {
+		if len(grouped[ns]) == 0 {
+			continue
+		}
+		fmt.Fprintln(tw, ns+" ("+strconv.Itoa(len(grouped[ns]))+")")
+		if conf.tree {
+			writeTree(tw, grouped[ns], ns, now)
+			continue
+		}
+		for _, d := range grouped[ns] {
+			fmt.Fprintln(tw, "  "+d.Name+"\t"+familyLine(d, now))
+		}
+	}
+	return tw.Flush()
+}
+
+//writeTree families of one namespace under their subsystem, the first name segment after the namespace
+func writeTree(w io.Writer, families []helpers.Data, ns string, now time.Time) {
+	var subsystems []string
+	bySubsystem := make(map[string][]helpers.Data)
+	for _, d := range families {
+		subsystem := strings.SplitN(strings.TrimPrefix(d.Name, ns), "_", 2)[0]
+		if _, ok := bySubsystem[subsystem]; !ok {
+			subsystems = append(subsystems, subsystem)
+		}
+		bySubsystem[subsystem] = append(bySubsystem[subsystem], d)
+	}
+	for i, subsystem := range subsystems {
+		branch, indent := "├── ", "│   "
+		if i == len(subsystems)-1 {
+			branch, indent = "└── ", "    "
+		}
+		fmt.Fprintln(w, branch+subsystem)
+		for j, d := range bySubsystem[subsystem] {
+			leaf := "├── "
+			if j == len(bySubsystem[subsystem])-1 {
+				leaf = "└── "
+			}
+			fmt.Fprintln(w, indent+leaf+d.Name+"\t"+familyLine(d, now))
+		}
+	}
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+var listData = []helpers.Data{
+	{Name: "sys_memory_used_bytes", Type: "GAUGE", Help: "Memory used", Metric: []helpers.Metrics{{Value: "1"}}},
+	{Name: "jfrt_db_connections_active_total", Type: "GAUGE", Help: "Active connections", Metric: []helpers.Metrics{{Value: "4"}}},
+	{Name: "jfrt_http_connections_leased_total", Type: "GAUGE", Metric: []helpers.Metrics{{Value: "2"}, {Value: "3"}}},
+	{Name: "jfrt_artifacts_gc_runs_total", Type: "COUNTER", Metric: []helpers.Metrics{{Value: "7"}}},
+	{Name: "go_goroutines", Type: "GAUGE", Metric: []helpers.Metrics{{Value: "30"}}},
+}
+
+func listLines(t *testing.T, conf *ListConfiguration) []string {
+	var out bytes.Buffer
+	assert.NoError(t, metricsList(&out, listData, conf))
+	return strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+}
+
+func TestMetricsList(t *testing.T) {
+	lines := listLines(t, &ListConfiguration{})
+	assert.Equal(t, "Found 5 metrics", lines[0])
+	assert.Len(t, lines, 6)
+	assert.True(t, strings.HasPrefix(lines[1], "go_goroutines "))
+	assert.Regexp(t, `^jfrt_http_connections_leased_total +gauge +2 series +- *$`, lines[4])
+	assert.Regexp(t, `^sys_memory_used_bytes +gauge +1 series +- +Memory used$`, lines[5])
+}
+
+func TestMetricsListType(t *testing.T) {
+	lines := listLines(t, &ListConfiguration{typeNames: []string{"counter"}})
+	assert.Equal(t, "Found 1 metrics", lines[0])
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasPrefix(lines[1], "jfrt_artifacts_gc_runs_total "))
+	}
+}
+
+func TestMetricsListGroup(t *testing.T) {
+	lines := listLines(t, &ListConfiguration{group: true})
+	assert.Equal(t, "jfrt_ (3)", lines[1])
+	assert.True(t, strings.HasPrefix(lines[2], "  jfrt_artifacts_gc_runs_total "))
+	assert.Equal(t, "sys_ (1)", lines[5])
+	assert.Equal(t, "other (1)", lines[7])
+	assert.True(t, strings.HasPrefix(lines[8], "  go_goroutines "))
+}
+
+func TestMetricsListTree(t *testing.T) {
+	lines := listLines(t, &ListConfiguration{tree: true, typeNames: []string{"gauge"}})
+	assert.Equal(t, "Found 4 metrics", lines[0])
+	assert.Equal(t, "jfrt_ (2)", lines[1])
+	assert.Equal(t, "├── db", strings.TrimSpace(lines[2]))
+	assert.True(t, strings.HasPrefix(lines[3], "│   └── jfrt_db_connections_active_total "))
+	assert.Equal(t, "└── http", strings.TrimSpace(lines[4]))
+	assert.True(t, strings.HasPrefix(lines[5], "    └── jfrt_http_connections_leased_total "))
+	assert.Equal(t, "sys_ (1)", lines[6])
+	assert.Equal(t, "└── memory", strings.TrimSpace(lines[7]))
+}
@@ -0,0 +1,189 @@
+package commands
+
+import (
+	"errors"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//metricsSource where the dashboard polls its metrics from, a fake in tests
+type metricsSource interface {
+	fetch(offSetCounter int, interval int) ([]helpers.Data, string, int, error)
+}
+
+//artifactorySource the metrics API of a configured Artifactory server, or of one node of it when node is set. The
+//series of a node are tagged with its id
+type artifactorySource struct {
+	config *config.ArtifactoryDetails
+	node   string
+}
+
+func (source artifactorySource) fetch(offSetCounter int, interval int) ([]helpers.Data, string, int, error) {
+	data, lastUpdate, offset, err := helpers.GetMetricsData(source.config, offSetCounter, false, interval)
+	//an unreachable server, or one answering with an error, gives no metrics rather than an error
+	if err == nil && len(data) == 0 {
+		err = errors.New("No metrics returned by " + source.config.Url + ", see " + helpers.LogFileName + " for the response")
+	}
+	if source.node != "" {
+		data = helpers.TagNode(data, source.node)
+	}
+	return data, lastUpdate, offset, err
+}
+
+//pollSources one source per compared server, or per node of the HA cluster of the first server when conf lists nodes
+func pollSources(configs []*config.ArtifactoryDetails, conf *GraphConfiguration) []artifactorySource {
+	var sources []artifactorySource
+	if len(conf.nodes) > 0 {
+		for _, node := range conf.nodes {
+			sources = append(sources, artifactorySource{config: helpers.NodeConfig(configs[0], node), node: node.ID})
+		}
+		return sources
+	}
+	for _, serverConfig := range configs {
+		sources = append(sources, artifactorySource{config: serverConfig})
+	}
+	return sources
+}
+
+//startPollers poll every source on a goroutine of its own, polls are tagged with the index of their source. Returns
+//the control channel of each poller
+func startPollers(sources []artifactorySource, interval time.Duration, polls chan<- poll, done <-chan struct{}) []chan<- pollerControl {
+	var controls []chan<- pollerControl
+	for i, source := range sources {
+		control := make(chan pollerControl, 16)
+		controls = append(controls, control)
+		go pollMetrics(source, i, interval, polls, control, done)
+	}
+	return controls
+}
+
+type pollerCommand int
+
+const (
+	pollNow pollerCommand = iota
+	pausePolling
+	resumePolling
+	setInterval
+)
+
+//pollerControl request from the UI loop to the poller, interval is only used by setInterval
+type pollerControl struct {
+	command  pollerCommand
+	interval time.Duration
+}
+
+//poll one fetch of the metrics, handed from the poller to the UI loop. server is the index of the server polled
+//when comparing several
+type poll struct {
+	server      int
+	data        []helpers.Data
+	lastUpdate  string
+	offset      int
+	time        time.Time
+	computeTime time.Time
+	err         error
+}
+
+//pollMetrics fetch every interval until done is closed. Runs on its own goroutine and shares nothing with the
+//UI loop: each poll is handed over on polls, the dashboard state is only ever touched by runDashboard. control pauses,
+//resumes, forces a poll or changes the interval. Every poll is tagged with server
+func pollMetrics(source metricsSource, server int, interval time.Duration, polls chan<- poll, control <-chan pollerControl, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer func() { ticker.Stop() }()
+	offSetCounter := 0
+	paused := false
+	//handle a control request, true when it asks for a poll right away
+	handle := func(c pollerControl) bool {
+		switch c.command {
+		case pollNow:
+			return true
+		case pausePolling:
+			paused = true
+		case resumePolling:
+			paused = false
+		case setInterval:
+			interval = c.interval
+			ticker.Stop()
+			ticker = time.NewTicker(interval)
+		}
+		return false
+	}
+
+	for {
+		fetch := false
+		select {
+		case <-done:
+			return
+		case c := <-control:
+			fetch = handle(c)
+		case <-ticker.C:
+			fetch = !paused
+		}
+		if !fetch {
+			continue
+		}
+		p := poll{server: server, time: time.Now()}
+		p.data, p.lastUpdate, p.offset, p.err = source.fetch(offSetCounter, int(interval/time.Second))
+		p.computeTime = time.Now()
+		offSetCounter = p.offset
+		//keep taking control requests while the UI loop is busy, so neither side blocks the other
+		for sent := false; !sent; {
+			select {
+			case polls <- p:
+				sent = true
+			case c := <-control:
+				handle(c)
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+//screen what the UI loop drives, a dashboard or several of them compared side by side
+type screen interface {
+	resize(width, height int)
+	handleKey(id string) bool
+	handleControl(id string) (pollerControl, bool)
+	apply(p poll)
+	render()
+}
+
+//runDashboard the UI loop and the single owner of the dashboard: applies polls and key events in order, passes polling
+//controls on to every poller and renders. render gets true when the screen must be cleared first. Failed polls are
+//shown by the screen, they never end the session
+func runDashboard(d screen, events <-chan ui.Event, polls <-chan poll, controls []chan<- pollerControl, render func(clear bool)) error {
+	for {
+		select {
+		case e := <-events:
+			switch e.ID { // event string/identifier
+			case "q", "<C-c>": // press 'q' or 'C-c' to quit
+				return nil
+			case "<Resize>":
+				payload := e.Payload.(ui.Resize)
+				d.resize(payload.Width, payload.Height)
+				render(true)
+			default:
+				if c, ok := d.handleControl(e.ID); ok {
+					//a poller stuck in a slow fetch with a full buffer misses the request instead of freezing the UI.
+					//Requests carry the whole state, paused or the new interval, so the next one catches it up
+					for _, control := range controls {
+						select {
+						case control <- c:
+						default:
+						}
+					}
+					render(false)
+				} else if d.handleKey(e.ID) {
+					render(true)
+				}
+			}
+		case p := <-polls:
+			d.apply(p)
+			render(false)
+		}
+	}
+}
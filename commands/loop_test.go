@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+	"github.com/stretchr/testify/assert"
+)
+
+//fakeSource metrics that change on every fetch, in place of an Artifactory server
+type fakeSource struct {
+	calls int32
+	err   error
+}
+
+func (source *fakeSource) fetch(offSetCounter int, interval int) ([]helpers.Data, string, int, error) {
+	calls := atomic.AddInt32(&source.calls, 1)
+	if source.err != nil {
+		return nil, "", 0, source.err
+	}
+	text := strings.Replace(dashboardText, `pool="npm-remote"} 2`, `pool="npm-remote"} `+strconv.Itoa(int(calls)%50), 1)
+	jsonText, err := helpers.MetricsTextToJSON([]byte(text), false)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	data, err := helpers.ParseMetricsJSON(jsonText)
+	return data, "", offSetCounter + 1, err
+}
+
+func testDashboard(t *testing.T) *dashboard {
+	layout, err := loadLayout("")
+	assert.NoError(t, err)
+	d, err := newDashboard(layout, testGraphConfiguration())
+	assert.NoError(t, err)
+	d.config = &config.ArtifactoryDetails{ServerId: "test"}
+	d.resize(146, 59)
+	return d
+}
+
+//TestDashboardLoop polls, key events and resizes all hitting the dashboard at once. Run with -race
+func TestDashboardLoop(t *testing.T) {
+	d := testDashboard(t)
+	source := new(fakeSource)
+	polls := make(chan poll)
+	control := make(chan pollerControl, 16)
+	done := make(chan struct{})
+	defer close(done)
+	go pollMetrics(source, 0, time.Millisecond, polls, control, done)
+
+	events := make(chan ui.Event)
+	renders := 0
+	result := make(chan error)
+	go func() {
+		result <- runDashboard(d, events, polls, []chan<- pollerControl{control}, func(bool) { renders++ })
+	}()
+
+	for atomic.LoadInt32(&source.calls) < 20 {
+		for _, id := range []string{"<Tab>", "[", "<Down>", "<Enter>", "]", "<Escape>", "?", "<End>", "6", "?", "<PageDown>", "r", "1"} {
+			events <- ui.Event{ID: id}
+		}
+		events <- ui.Event{ID: "<Resize>", Payload: ui.Resize{Width: 80, Height: 24}}
+		events <- ui.Event{ID: "<Resize>", Payload: ui.Resize{Width: 146, Height: 59}}
+	}
+	events <- ui.Event{ID: "q"}
+	assert.NoError(t, <-result)
+	assert.True(t, renders > 20)
+	assert.NotEmpty(t, d.pools)
+	_, ok := d.store.Last(poolKey("npm-remote", "Leased"))
+	assert.True(t, ok)
+}
+
+//TestDashboardLoopBusyPoller control keys do not block the UI loop on a poller that does not take them
+func TestDashboardLoopBusyPoller(t *testing.T) {
+	d := testDashboard(t)
+	control := make(chan pollerControl, 1)
+	events := make(chan ui.Event)
+	result := make(chan error)
+	go func() {
+		result <- runDashboard(d, events, make(chan poll), []chan<- pollerControl{control}, func(bool) {})
+	}()
+	for _, id := range []string{"r", "p", "+", "q"} {
+		select {
+		case events <- ui.Event{ID: id}:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the UI loop is blocked on the control of the poller")
+		}
+	}
+	assert.NoError(t, <-result)
+	assert.Equal(t, pollerControl{command: pollNow}, <-control)
+}
+
+//TestDashboardLoopError failed polls are shown in the meta pane and the status line, the session goes on
+func TestDashboardLoopError(t *testing.T) {
+	d := testDashboard(t)
+	polls := make(chan poll)
+	control := make(chan pollerControl, 16)
+	done := make(chan struct{})
+	defer close(done)
+	go pollMetrics(&fakeSource{err: errors.New("connection [refused]")}, 0, time.Millisecond, polls, control, done)
+
+	events := make(chan ui.Event)
+	result := make(chan error)
+	//the dashboard is only read on the UI loop until it quits
+	failed := make(chan struct{})
+	go func() {
+		failures := 0
+		result <- runDashboard(d, events, polls, []chan<- pollerControl{control}, func(bool) {
+			if d.failure != "" {
+				if failures++; failures == 3 {
+					close(failed)
+				}
+			}
+		})
+	}()
+	for waiting := true; waiting; {
+		select {
+		case <-failed:
+			waiting = false
+		case events <- ui.Event{ID: "r"}:
+		case err := <-result:
+			t.Fatal("the session ended: ", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("no failed polls")
+		}
+	}
+	events <- ui.Event{ID: "q"}
+	assert.NoError(t, <-result)
+
+	assert.Nil(t, d.last)
+	assert.Contains(t, d.failure, ": connection [refused]")
+	meta := d.pages[0].panels[0].widget.(*widgets.Paragraph)
+	assert.Contains(t, meta.Text, "Server url: test")
+	assert.Contains(t, meta.Text, ": connection (refused)](fg:white,bg:red)")
+	assert.True(t, strings.HasPrefix(d.status.Text, "[Poll failed at "), d.status.Text)
+
+	//the next poll that works clears it
+	d.apply(poll{data: parseDashboardMetrics(t, dashboardText), time: time.Now()})
+	assert.Equal(t, "", d.failure)
+	assert.NotContains(t, meta.Text, "refused")
+	assert.Equal(t, "[No threshold breached](fg:green)", d.status.Text)
+}
+
+func TestPollerControl(t *testing.T) {
+	source := new(fakeSource)
+	polls := make(chan poll)
+	control := make(chan pollerControl)
+	done := make(chan struct{})
+	defer close(done)
+	go pollMetrics(source, 0, time.Hour, polls, control, done)
+
+	//nothing is fetched before the first tick unless asked for
+	control <- pollerControl{command: pollNow}
+	p := <-polls
+	assert.Equal(t, 1, p.offset)
+	control <- pollerControl{command: setInterval, interval: time.Millisecond}
+	<-polls
+	control <- pollerControl{command: pausePolling}
+	//a poll fetched before the pause may still be waiting
+	select {
+	case <-polls:
+	case <-time.After(20 * time.Millisecond):
+	}
+	calls := atomic.LoadInt32(&source.calls)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, calls, atomic.LoadInt32(&source.calls))
+	//a forced poll still goes through while paused
+	control <- pollerControl{command: pollNow}
+	<-polls
+	assert.Equal(t, calls+1, atomic.LoadInt32(&source.calls))
+	control <- pollerControl{command: resumePolling}
+	<-polls
+}
+
+func TestDashboardControlKeys(t *testing.T) {
+	d := testDashboard(t)
+	_, ok := d.handleControl("x")
+	assert.False(t, ok)
+	result := testPollResult(t, dashboardText)
+	result.conf = d.conf
+	d.update(result)
+	meta := d.pages[0].panels[0].widget.(*widgets.Paragraph)
+
+	c, ok := d.handleControl("p")
+	assert.True(t, ok)
+	assert.Equal(t, pausePolling, c.command)
+	assert.Contains(t, meta.Text, "Polling paused")
+	c, _ = d.handleControl("<Space>")
+	assert.Equal(t, resumePolling, c.command)
+	assert.Contains(t, meta.Text, "Polling interval: every 1 seconds")
+
+	c, ok = d.handleControl("+")
+	assert.True(t, ok)
+	assert.Equal(t, pollerControl{command: setInterval, interval: 2 * time.Second}, c)
+	assert.Contains(t, meta.Text, "every 2 seconds")
+	d.handleControl("-")
+	_, ok = d.handleControl("-")
+	assert.False(t, ok)
+	assert.Equal(t, 1, d.conf.interval)
+	c, _ = d.handleControl("r")
+	assert.Equal(t, pollNow, c.command)
+
+	assert.True(t, d.handleKey("?"))
+	assert.True(t, d.showHelp)
+	assert.True(t, d.handleKey("<Escape>"))
+	assert.False(t, d.showHelp)
+}
@@ -1,13 +1,16 @@
 package commands
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	helpers "github.com/jfrog/frogvision/utils"
 	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
 )
 
 func GetMetricsCommand() components.Command {
@@ -28,7 +31,27 @@ func getMetricsArguments() []components.Argument {
 	return []components.Argument{
 		{
 			Name:        "list",
-			Description: "list metrics.",
+			Description: "list metrics with their type, HELP text, series count and UPDATED age.",
+		},
+		{
+			Name:        "pools",
+			Description: "Remote repository connection pools with leased, pending, available and max connections.",
+		},
+		{
+			Name:        "get <selector>",
+			Description: "Get only the metrics matching a PromQL-style selector, e.g. 'jfrt_http_connections_leased_total{pool=~\"maven.*\"}' or a name regex such as 'jfrt_db_.*'.",
+		},
+		{
+			Name:        "describe <name>",
+			Description: "Show the HELP, TYPE, UPDATED time and series of a metric family.",
+		},
+		{
+			Name:        "diff <a> <b>",
+			Description: "Compare two captures, each a snapshot file saved from `metrics --raw` or `metrics --min`, or 'live' for the server now.",
+		},
+		{
+			Name:        "watch [selector]",
+			Description: "Poll metrics every --interval seconds and stream timestamped samples (ndjson or csv) to stdout or --output.",
 		},
 	}
 }
@@ -45,6 +68,56 @@ func getMetricsFlags() []components.Flag {
 			Description:  "Get minimum JSON from Artifactory (no whitespace)",
 			DefaultValue: false,
 		},
+		components.StringFlag{
+			Name:         "format",
+			Description:  "Output format: " + strings.Join(helpers.OutputFormats, ", "),
+			DefaultValue: "json",
+		},
+		components.BoolFlag{
+			Name:         "group",
+			Description:  "Group list output by namespace (jfrt_, sys_, app_, jfxr_, jfrou_)",
+			DefaultValue: false,
+		},
+		components.BoolFlag{
+			Name:         "tree",
+			Description:  "Render list output as a namespace / subsystem tree",
+			DefaultValue: false,
+		},
+		components.StringFlag{
+			Name:         "type",
+			Description:  "Only list metrics of these comma separated types, e.g. gauge,counter",
+			DefaultValue: "",
+		},
+		components.StringFlag{
+			Name:         "interval",
+			Description:  "Polling interval in seconds for watch",
+			DefaultValue: "5",
+		},
+		components.StringFlag{
+			Name:         "duration",
+			Description:  "How long to watch for, e.g. 30m or 2h. Runs until interrupted when empty",
+			DefaultValue: "",
+		},
+		components.StringFlag{
+			Name:         "output",
+			Description:  "File to write watch samples to instead of stdout",
+			DefaultValue: "",
+		},
+		components.StringFlag{
+			Name:         "max-size",
+			Description:  "Rotate the watch output file after this many megabytes",
+			DefaultValue: "100",
+		},
+		components.StringFlag{
+			Name:         "max-files",
+			Description:  "Number of rotated watch output files to keep",
+			DefaultValue: "5",
+		},
+		components.StringFlag{
+			Name:         "increase-window",
+			Description:  "Time span the increase of counters is streamed over by watch, e.g. 5m or 1h",
+			DefaultValue: "5m",
+		},
 	}
 }
 
@@ -58,17 +131,30 @@ type MetricsConfiguration struct {
 	repeat    int
 	prefix    string
 	min       bool
+	format    string
 }
 
 func MetricsCmd(c *components.Context) error {
 
+	//diff may compare two snapshot files, so it only needs the server when a side is live
+	if len(c.Arguments) == 3 && c.Arguments[0] == "diff" {
+		//the diff is for people unless json is asked for, the format default is meant for the other subcommands
+		format := "table"
+		if len(commandLineValues(GetMetricsCommand(), "format")) > 0 {
+			format = c.GetStringFlagValue("format")
+		}
+		return metricsDiffCmd(c.Arguments[1], c.Arguments[2], format)
+	}
+
 	config, err := helpers.GetConfig()
 	if err != nil {
-		return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+		return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
 	}
 
 	var conf = new(MetricsConfiguration)
 	//conf.addressee = c.Arguments[0]
+	conf.min = c.GetBoolFlagValue("min")
+	conf.format = c.GetStringFlagValue("format")
 
 	if len(c.Arguments) == 0 {
 		conf.raw = c.GetBoolFlagValue("raw")
@@ -82,13 +168,19 @@ func MetricsCmd(c *components.Context) error {
 			return nil
 		}
 
-		conf.min = c.GetBoolFlagValue("min")
+		if conf.format != "json" {
+			metricsData, err := getMetricsData(config)
+			if err != nil {
+				return err
+			}
+			return helpers.WriteMetrics(os.Stdout, metricsData, conf.format, !conf.min)
+		}
 
 		if conf.min {
 			//return json as is, no white space
 			data, err := helpers.GetMetricsDataJSON(config, false)
 			if err != nil {
-				return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+				return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
 			}
 			fmt.Println(string(data))
 			return nil
@@ -97,7 +189,7 @@ func MetricsCmd(c *components.Context) error {
 		//else pretty print json
 		data, err := helpers.GetMetricsDataJSON(config, true)
 		if err != nil {
-			return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+			return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
 		}
 		fmt.Println(string(data))
 		return nil
@@ -107,28 +199,109 @@ func MetricsCmd(c *components.Context) error {
 		var err error
 		switch arg := c.Arguments[0]; arg {
 		case "list":
-			jsonText, err := helpers.GetMetricsDataJSON(config, false)
+			metricsData, err := getMetricsData(config)
 			if err != nil {
-				return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+				return err
 			}
-			var metricsData []helpers.Data
-			err = json.Unmarshal(jsonText, &metricsData)
+			listConf := &ListConfiguration{group: c.GetBoolFlagValue("group"), tree: c.GetBoolFlagValue("tree")}
+			if c.GetStringFlagValue("type") != "" {
+				listConf.typeNames = strings.Split(c.GetStringFlagValue("type"), ",")
+			}
+			return metricsList(os.Stdout, metricsData, listConf)
+		case "pools":
+			metricsData, err := getMetricsData(config)
 			if err != nil {
-				return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+				return err
 			}
-			fmt.Println("Found", len(metricsData), "metrics")
-			for i := range metricsData {
-				fmt.Println(metricsData[i].Name)
+			return helpers.WritePools(os.Stdout, helpers.GetConnectionPools(metricsData), conf.format, !conf.min)
+		case "get":
+			err = errors.New("Missing selector, usage: metrics get <selector>")
+		case "describe":
+			err = errors.New("Missing metric name, usage: metrics describe <name>")
+		case "diff":
+			err = errors.New("Missing snapshots, usage: metrics diff <a> <b>")
+		case "watch":
+			watchConf, err := getWatchConfiguration(c, "")
+			if err != nil {
+				return err
 			}
-			return nil
+			return metricsWatch(config, watchConf)
 		case "linux":
 			fmt.Println("Linux.")
 		default:
 			err = errors.New("Unrecognized argument:" + arg)
 		}
 
-		return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + string(helpers.Trace().Line))
+		return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
+	}
+	if len(c.Arguments) == 2 {
+		switch arg := c.Arguments[0]; arg {
+		case "get":
+			metricsData, err := getMetricsData(config)
+			if err != nil {
+				return err
+			}
+			selected, err := helpers.SelectMetrics(metricsData, c.Arguments[1])
+			if err != nil {
+				return err
+			}
+			if len(selected) == 0 {
+				return errors.New("No metrics matched " + c.Arguments[1])
+			}
+			return helpers.WriteMetrics(os.Stdout, selected, conf.format, !conf.min)
+		case "watch":
+			watchConf, err := getWatchConfiguration(c, c.Arguments[1])
+			if err != nil {
+				return err
+			}
+			return metricsWatch(config, watchConf)
+		case "describe":
+			metricsData, err := getMetricsData(config)
+			if err != nil {
+				return err
+			}
+			return describeMetric(metricsData, c.Arguments[1], conf)
+		case "diff":
+			return errors.New("Missing second snapshot, usage: metrics diff <a> <b>")
+		default:
+			return errors.New("Unrecognized argument:" + arg + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
+		}
+	}
+	return errors.New("Wrong number of arguments. Expected: 0, 1, 2 or 3, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+
+}
+
+//getMetricsData fetch and unmarshal the current metrics
+func getMetricsData(config *config.ArtifactoryDetails) ([]helpers.Data, error) {
+	jsonText, err := helpers.GetMetricsDataJSON(config, false)
+	if err != nil {
+		return nil, errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
 	}
-	return errors.New("Wrong number of arguments. Expected: 0 or 1, " + "Received: " + strconv.Itoa(len(c.Arguments)))
+	return helpers.ParseMetricsJSON(jsonText)
+}
 
+//describeMetric print the metadata of one family followed by its series in the requested format
+func describeMetric(metricsData []helpers.Data, name string, conf *MetricsConfiguration) error {
+	for i := range metricsData {
+		if metricsData[i].Name != name {
+			continue
+		}
+		updated := "unknown"
+		if updatedTime, ok := metricsData[i].UpdatedTime(); ok {
+			updated = updatedTime.Format("2006.01.02 15:04:05") + " (" + helpers.FormatAge(time.Since(updatedTime)) + " ago)"
+		}
+		fmt.Println("Name:    " + metricsData[i].Name)
+		fmt.Println("Type:    " + strings.ToLower(metricsData[i].Type))
+		fmt.Println("Help:    " + metricsData[i].Help)
+		fmt.Println("Updated: " + updated)
+		fmt.Println("Series:  " + strconv.Itoa(len(metricsData[i].Metric)))
+		fmt.Println()
+		//describe is for people, json is still available through metrics get
+		format := conf.format
+		if format == "json" {
+			format = "table"
+		}
+		return helpers.WriteMetrics(os.Stdout, metricsData[i:i+1], format, !conf.min)
+	}
+	return errors.New("No metric named " + name + ", see metrics list")
 }
@@ -0,0 +1,230 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	helpers "github.com/jfrog/frogvision/utils"
+)
+
+//dashboardModel the values the dashboard derives from one poll, independent of any terminal. The built-in sources
+//read from the same functions, graph --headless prints it as is
+type dashboardModel struct {
+	Time       time.Time                `json:"time"`
+	Server     string                   `json:"server"`
+	Node       string                   `json:"node,omitempty"`
+	Metrics    int                      `json:"metrics"`
+	Stale      int                      `json:"stale"`
+	Storage    *usage                   `json:"storage,omitempty"`
+	Heap       *heap                    `json:"heap,omitempty"`
+	CPUPercent *float64                 `json:"cpu_percent,omitempty"`
+	DB         *dbPool                  `json:"db,omitempty"`
+	PoolTotals helpers.ConnectionPool   `json:"pool_totals"`
+	Pools      []helpers.ConnectionPool `json:"pools"`
+	GC         *gcRun                   `json:"gc,omitempty"`
+}
+
+//usage used part of a total, in bytes
+type usage struct {
+	Used    float64 `json:"used_bytes"`
+	Total   float64 `json:"total_bytes"`
+	Percent float64 `json:"percent"`
+}
+
+//heap the JVM heap. Committed is what the JVM currently holds from the OS, it grows up to Max
+type heap struct {
+	Used      float64 `json:"used_bytes"`
+	Committed float64 `json:"committed_bytes"`
+	Max       float64 `json:"max_bytes"`
+	//Percent used of max
+	Percent    float64 `json:"percent"`
+	Processors int     `json:"processors,omitempty"`
+}
+
+//dbPool the Artifactory database connection pool
+type dbPool struct {
+	Active  float64 `json:"active"`
+	Idle    float64 `json:"idle"`
+	MinIdle float64 `json:"min_idle"`
+	Max     float64 `json:"max"`
+	//Percent active of max
+	Percent float64 `json:"percent"`
+}
+
+//gcRun the last garbage collection run
+type gcRun struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Type     string    `json:"type"`
+	Status   string    `json:"status"`
+	Binaries float64   `json:"binaries"`
+	Duration float64   `json:"duration_seconds"`
+	Cleaned  float64   `json:"cleaned_bytes"`
+	Current  float64   `json:"current_size_bytes"`
+}
+
+func newDashboardModel(result *pollResult) *dashboardModel {
+	model := &dashboardModel{
+		Time:       result.time,
+		Node:       result.node,
+		Metrics:    len(result.data),
+		Storage:    storageUsage(result),
+		Heap:       heapModel(result),
+		DB:         dbPoolModel(result),
+		PoolTotals: helpers.PoolTotals(result.pools),
+		Pools:      result.pools,
+		GC:         gcModel(result),
+	}
+	if result.config != nil {
+		model.Server = result.config.ServerId
+	}
+	model.Stale, _ = staleFamilies(result)
+	if cpu, ok := cpuPercent(result); ok {
+		model.CPUPercent = &cpu
+	}
+	return model
+}
+
+//newUsage nil when total is missing, a percent is meaningless then
+func newUsage(used, total float64) *usage {
+	if total <= 0 {
+		return nil
+	}
+	return &usage{Used: used, Total: total, Percent: used / total * 100}
+}
+
+//storageUsage used disk space of the filestore
+func storageUsage(result *pollResult) *usage {
+	free, ok := result.value("app_disk_free_bytes")
+	total, _ := result.value("app_disk_total_bytes")
+	if !ok {
+		return nil
+	}
+	return newUsage(total-free, total)
+}
+
+//heapModel used = total - free, the free memory is the unused part of the committed heap
+func heapModel(result *pollResult) *heap {
+	free, ok := result.value("jfrt_runtime_heap_freememory_bytes")
+	total, _ := result.value("jfrt_runtime_heap_totalmemory_bytes")
+	if !ok || total <= 0 {
+		return nil
+	}
+	h := &heap{Used: total - free, Committed: total}
+	h.Max, _ = result.value("jfrt_runtime_heap_maxmemory_bytes")
+	if h.Max > 0 {
+		h.Percent = h.Used / h.Max * 100
+	}
+	processors, _ := result.value("jfrt_runtime_heap_processors_total")
+	h.Processors = int(processors)
+	return h
+}
+
+//cpuPercent CPU utilization from the rate of the total CPU time counter, spread over the available processors. False
+//until two polls have been seen
+func cpuPercent(result *pollResult) (float64, bool) {
+	cpuRate, ok := result.rates.Rate(result.key("sys_cpu_totaltime_seconds"))
+	if !ok {
+		return 0, false
+	}
+	procs, ok := result.value("jfrt_runtime_heap_processors_total")
+	if !ok || procs < 1 {
+		procs = 1
+	}
+	return cpuRate / procs * 100, true
+}
+
+func dbPoolModel(result *pollResult) *dbPool {
+	active, ok := result.value("jfrt_db_connections_active_total")
+	if !ok {
+		return nil
+	}
+	db := &dbPool{Active: active}
+	db.Max, _ = result.value("jfrt_db_connections_max_active_total")
+	db.Idle, _ = result.value("jfrt_db_connections_idle_total")
+	db.MinIdle, _ = result.value("jfrt_db_connections_min_idle_total")
+	if db.Max > 0 {
+		db.Percent = active / db.Max * 100
+	}
+	return db
+}
+
+func gcModel(result *pollResult) *gcRun {
+	var gc *gcRun
+	for i := range result.data {
+		if result.data[i].Name != "jfrt_artifacts_gc_duration_seconds" || len(result.data[i].Metric) == 0 {
+			continue
+		}
+		labels := result.data[i].Metric[0].Labels
+		gc = &gcRun{Type: labels.GcType(), Status: labels.GcStatus()}
+		var ok bool
+		if gc.Start, ok = labels.GcStart(); !ok {
+			helpers.LogRestFile.Error("invalid GC start label ", labels.Get("start"), " at "+string(helpers.Trace().Fn)+" on line "+strconv.Itoa(helpers.Trace().Line))
+		}
+		if gc.End, ok = labels.GcEnd(); !ok {
+			helpers.LogRestFile.Error("invalid GC end label ", labels.Get("end"), " at "+string(helpers.Trace().Fn)+" on line "+strconv.Itoa(helpers.Trace().Line))
+		}
+		gc.Duration, _ = result.value("jfrt_artifacts_gc_duration_seconds")
+	}
+	if gc == nil {
+		return nil
+	}
+	gc.Binaries, _ = result.value("jfrt_artifacts_gc_binaries_total")
+	gc.Cleaned, _ = result.value("jfrt_artifacts_gc_size_cleaned_bytes")
+	gc.Current, _ = result.value("jfrt_artifacts_gc_current_size_bytes")
+	return gc
+}
+
+//staleFamilies families Artifactory stopped refreshing, as opposed to genuinely flat values, and the oldest of them
+func staleFamilies(result *pollResult) (int, helpers.Data) {
+	var staleCount int
+	var oldest helpers.Data
+	for i := range result.data {
+		if result.data[i].IsStale(result.conf.staleAfter, result.time) {
+			staleCount++
+			if oldest.Updated == "" || helpers.StringToInt64(result.data[i].Updated) < helpers.StringToInt64(oldest.Updated) {
+				oldest = result.data[i]
+			}
+		}
+	}
+	return staleCount, oldest
+}
+
+//line the model as one line of key=value pairs for logs, times in UTC
+func (model *dashboardModel) line() string {
+	fields := []string{model.Time.UTC().Format(time.RFC3339), "server=" + model.Server}
+	if model.Node != "" {
+		fields = append(fields, "node="+model.Node)
+	}
+	fields = append(fields, "metrics="+strconv.Itoa(model.Metrics))
+	if model.Stale > 0 {
+		fields = append(fields, "stale="+strconv.Itoa(model.Stale))
+	}
+	if model.Storage != nil {
+		fields = append(fields, "storage="+formatPercent(model.Storage.Percent), "storage_used_bytes="+formatNumber(model.Storage.Used))
+	}
+	if model.Heap != nil {
+		fields = append(fields, "heap="+formatPercent(model.Heap.Percent), "heap_used_bytes="+formatNumber(model.Heap.Used), "heap_committed_bytes="+formatNumber(model.Heap.Committed))
+	}
+	if model.CPUPercent != nil {
+		fields = append(fields, "cpu="+formatPercent(*model.CPUPercent))
+	}
+	if model.DB != nil {
+		fields = append(fields, "db_active="+formatNumber(model.DB.Active)+"/"+formatNumber(model.DB.Max), "db_idle="+formatNumber(model.DB.Idle))
+	}
+	totals := model.PoolTotals
+	fields = append(fields, "pools="+strconv.Itoa(len(model.Pools)), "pool_leased="+strconv.Itoa(totals.Leased)+"/"+strconv.Itoa(totals.Max), "pool_pending="+strconv.Itoa(totals.Pending))
+	if model.GC != nil {
+		fields = append(fields, "gc_status="+model.GC.Status, "gc_end="+model.GC.End.UTC().Format(time.RFC3339), "gc_cleaned_bytes="+formatNumber(model.GC.Cleaned))
+	}
+	return strings.Join(fields, " ")
+}
+
+func formatPercent(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64) + "%"
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
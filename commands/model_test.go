@@ -0,0 +1,121 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const modelText = dashboardText + `# HELP jfrt_runtime_heap_freememory_bytes Free Memory
+# TYPE jfrt_runtime_heap_freememory_bytes gauge
+jfrt_runtime_heap_freememory_bytes 100 1607287853275
+# HELP jfrt_runtime_heap_totalmemory_bytes Total Memory
+# TYPE jfrt_runtime_heap_totalmemory_bytes gauge
+jfrt_runtime_heap_totalmemory_bytes 500 1607287853275
+# HELP jfrt_runtime_heap_maxmemory_bytes Max Memory
+# TYPE jfrt_runtime_heap_maxmemory_bytes gauge
+jfrt_runtime_heap_maxmemory_bytes 1600 1607287853275
+# HELP jfrt_runtime_heap_processors_total Available Processors
+# TYPE jfrt_runtime_heap_processors_total counter
+jfrt_runtime_heap_processors_total 4 1607287853275
+# HELP sys_cpu_totaltime_seconds Total CPU time
+# TYPE sys_cpu_totaltime_seconds counter
+sys_cpu_totaltime_seconds 100 1607287853275
+# HELP jfrt_artifacts_gc_duration_seconds Time taken by Garbage Collection
+# TYPE jfrt_artifacts_gc_duration_seconds gauge
+jfrt_artifacts_gc_duration_seconds{end="1607284801199",start="1607284800142",status="COMPLETED",type="FULL"} 1.057 1607287853275
+# HELP jfrt_artifacts_gc_size_cleaned_bytes Total Bytes recovered by Garbage Collection
+# TYPE jfrt_artifacts_gc_size_cleaned_bytes gauge
+jfrt_artifacts_gc_size_cleaned_bytes{end="1607284801199",start="1607284800142",status="COMPLETED",type="FULL"} 2048 1607287853275
+`
+
+func TestDashboardModel(t *testing.T) {
+	result := testPollResult(t, modelText)
+	model := newDashboardModel(result)
+	assert.Equal(t, "test", model.Server)
+	assert.Equal(t, &usage{Used: 625, Total: 1000, Percent: 62.5}, model.Storage)
+	assert.Equal(t, &heap{Used: 400, Committed: 500, Max: 1600, Percent: 25, Processors: 4}, model.Heap)
+	assert.Equal(t, &dbPool{Active: 4, Max: 100, Percent: 4}, model.DB)
+	assert.Equal(t, helpers.ConnectionPool{Name: "total", Leased: 7, Max: 70}, model.PoolTotals)
+	assert.Len(t, model.Pools, 2)
+	assert.Equal(t, "COMPLETED", model.GC.Status)
+	assert.Equal(t, 1.057, model.GC.Duration)
+	assert.Equal(t, float64(2048), model.GC.Cleaned)
+	assert.Equal(t, int64(1607284801199), model.GC.End.UnixNano()/int64(time.Millisecond))
+	assert.Nil(t, model.CPUPercent)
+
+	//two CPU seconds per second over four processors
+	result.rates.Observe(result.data, result.time.Add(-time.Second))
+	result.data = parseDashboardMetrics(t, strings.Replace(modelText, "sys_cpu_totaltime_seconds 100", "sys_cpu_totaltime_seconds 102", 1))
+	result.rates.Observe(result.data, result.time)
+	model = newDashboardModel(result)
+	if assert.NotNil(t, model.CPUPercent) {
+		assert.Equal(t, float64(50), *model.CPUPercent)
+	}
+
+	empty := newDashboardModel(testPollResult(t, ""))
+	assert.Nil(t, empty.Storage)
+	assert.Nil(t, empty.Heap)
+	assert.Nil(t, empty.DB)
+	assert.Nil(t, empty.GC)
+}
+
+func TestHeapPanels(t *testing.T) {
+	d := testDashboard(t)
+	result := testPollResult(t, modelText)
+	d.update(result)
+	gauge := d.pages[0].panels[5].widget.(*widgets.Gauge)
+	assert.Equal(t, 25, gauge.Percent)
+	assert.Equal(t, "25% 400 B / 1.6kB", gauge.Label)
+
+	jvm := d.pages[4].panels
+	assert.Equal(t, "Used: 400 B (25.0% of max)\nCommitted: 500 B\nMax: 1.6kB\nProcessors: 4", jvm[2].widget.(*widgets.Paragraph).Text)
+	assert.Equal(t, []string{"[■ Used](fg:red) 400 B", "[■ Committed](fg:green) 500 B", "[■ Max](fg:yellow) 1.6kB"}, jvm[5].widget.(*widgets.List).Rows)
+	plot := jvm[4].widget.(*widgets.Plot)
+	assert.Len(t, plot.Data, 3)
+	assert.Equal(t, float64(500), plot.Data[1][len(plot.Data[1])-1])
+	assert.Contains(t, d.pages[0].panels[3].widget.(*widgets.Paragraph).Text, "Heap Total: 500 B")
+
+	d.update(testPollResult(t, dashboardText))
+	assert.Equal(t, "Not exported", jvm[2].widget.(*widgets.Paragraph).Text)
+	assert.Equal(t, []string{"No heap values"}, jvm[5].widget.(*widgets.List).Rows)
+}
+
+func TestRunHeadless(t *testing.T) {
+	data := parseDashboardMetrics(t, modelText)
+	polls := make(chan poll, 2)
+	pollTime := time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC)
+	polls <- poll{data: data, time: pollTime}
+	polls <- poll{data: data, time: pollTime.Add(time.Second)}
+	conf := testGraphConfiguration()
+	conf.format, conf.polls = "line", 2
+	var out bytes.Buffer
+	assert.NoError(t, runHeadless(&out, ioutil.Discard, []*config.ArtifactoryDetails{{ServerId: "test"}}, conf, polls, make(chan os.Signal)))
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "2020-12-06T20:50:30Z server=test metrics=12 storage=62.5% storage_used_bytes=625 heap=25.0% heap_used_bytes=400 heap_committed_bytes=500 db_active=4/100 db_idle=0 pools=2 pool_leased=7/70 pool_pending=0 gc_status=COMPLETED gc_end=2020-12-06T20:00:01Z gc_cleaned_bytes=2048", string(lines[0]))
+	assert.Contains(t, string(lines[1]), "cpu=0.0%")
+
+	conf.format, conf.polls = "json", 1
+	out.Reset()
+	polls <- poll{data: data, time: pollTime}
+	assert.NoError(t, runHeadless(&out, ioutil.Discard, []*config.ArtifactoryDetails{{ServerId: "test"}}, conf, polls, make(chan os.Signal)))
+	var model dashboardModel
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &model))
+	assert.Equal(t, 62.5, model.Storage.Percent)
+	assert.Equal(t, 7, model.PoolTotals.Leased)
+
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- os.Interrupt
+	conf.polls = 0
+	assert.NoError(t, runHeadless(&out, ioutil.Discard, nil, conf, make(chan poll), interrupt))
+}
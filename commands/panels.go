@@ -0,0 +1,340 @@
+package commands
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+)
+
+//minPlotPoints a line chart needs two points to draw anything
+const minPlotPoints = 2
+
+//plotAxesWidth columns of a plot taken by the y axis labels and the axis
+const plotAxesWidth = 5
+
+//plotView the part of the series store plots show: width worth of samples ending at end
+type plotView struct {
+	store *helpers.SeriesStore
+	end   time.Time
+	width time.Duration
+	//scrolled how far end is behind the latest poll
+	scrolled time.Duration
+}
+
+//title plot title suffix describing the window
+func (view plotView) title() string {
+	if view.scrolled == 0 {
+		return " (last " + helpers.FormatAge(view.width) + ")"
+	}
+	return " (" + helpers.FormatAge(view.width) + " ending " + helpers.FormatAge(view.scrolled) + " ago)"
+}
+
+//line values of key over the view, one point per plot column
+func (view plotView) line(key string, points int) []float64 {
+	if points < minPlotPoints {
+		points = minPlotPoints
+	}
+	return view.store.Window(key, view.end, view.width, points)
+}
+
+//panel runtime state of one layout panel
+type panel struct {
+	spec        PanelSpec
+	selector    *helpers.Selector
+	maxSelector *helpers.Selector
+	maxValue    float64
+	widget      ui.Drawable
+	//values of the last poll, plots and sparklines read their history from the store by key
+	values []namedValue
+	//level threshold level of the last poll
+	level level
+}
+
+//newPanel build the widget of a validated panel spec
+func newPanel(spec PanelSpec) (*panel, error) {
+	pn := &panel{spec: spec}
+	if spec.Metric != "" {
+		selector, err := helpers.ParseSelector(spec.Metric)
+		if err != nil {
+			return nil, err
+		}
+		pn.selector = selector
+	}
+	if spec.Max != "" {
+		if max, err := strconv.ParseFloat(spec.Max, 64); err == nil {
+			pn.maxValue = max
+		} else {
+			selector, err := helpers.ParseSelector(spec.Max)
+			if err != nil {
+				return nil, err
+			}
+			pn.maxSelector = selector
+		}
+	}
+
+	switch strings.ToLower(spec.Type) {
+	case "gauge":
+		g := widgets.NewGauge()
+		g.BarColor = spec.colors(ui.ColorGreen)[0]
+		g.LabelStyle = ui.NewStyle(ui.ColorBlue)
+		g.BorderStyle.Fg = ui.ColorWhite
+		pn.widget = g
+	case "plot":
+		p := widgets.NewPlot()
+		p.Data = [][]float64{make([]float64, minPlotPoints)}
+		p.MaxVal = floorMax(p.Data...)
+		p.DotMarkerRune = '.'
+		p.AxesColor = ui.ColorWhite
+		p.LineColors = spec.colors(p.LineColors...)
+		p.DrawDirection = widgets.DrawLeft
+		p.HorizontalScale = 1
+		pn.widget = p
+	case "barchart":
+		bc := widgets.NewBarChart()
+		bc.BarWidth = spec.BarWidth
+		if bc.BarWidth == 0 {
+			bc.BarWidth = 3
+		}
+		bc.BarColors = spec.colors(ui.ColorGreen)
+		bc.LabelStyles = []ui.Style{ui.NewStyle(ui.ColorWhite)}
+		bc.NumStyles = []ui.Style{ui.NewStyle(ui.ColorBlack)}
+		if spec.Format != "" {
+			bc.NumFormatter = spec.formatValue
+		}
+		pn.widget = bc
+	case "list":
+		l := widgets.NewList()
+		l.Rows = []string{}
+		l.TextStyle = ui.NewStyle(spec.colors(ui.ColorYellow)[0])
+		l.SelectedRowStyle = ui.NewStyle(ui.ColorBlack, spec.colors(ui.ColorYellow)[0])
+		l.WrapText = false
+		pn.widget = l
+	case "paragraph":
+		p := widgets.NewParagraph()
+		p.Text = "Initializing"
+		pn.widget = p
+	case "sparkline":
+		pn.widget = widgets.NewSparklineGroup(widgets.NewSparkline())
+	}
+	pn.block().Title = spec.Title
+	pn.widget.SetRect(spec.Rect[0], spec.Rect[1], spec.Rect[2], spec.Rect[3])
+	return pn, nil
+}
+
+//block the widget border and title
+func (pn *panel) block() *ui.Block {
+	switch w := pn.widget.(type) {
+	case *widgets.Gauge:
+		return &w.Block
+	case *widgets.Plot:
+		return &w.Block
+	case *widgets.BarChart:
+		return &w.Block
+	case *widgets.List:
+		return &w.Block
+	case *widgets.Paragraph:
+		return &w.Block
+	case *widgets.SparklineGroup:
+		return &w.Block
+	}
+	return nil
+}
+
+//collect this poll's values, from the built-in source or the metric selector
+func (pn *panel) collect(result *pollResult) panelData {
+	var data panelData
+	if pn.spec.Source != "" {
+		data = panelSources[pn.spec.Source](result)
+		for i := range data.values {
+			data.values[i].key = pn.spec.Source + "/" + data.values[i].name
+		}
+	} else {
+		data = panelData{values: selectValues(pn.selector, result.data, pn.spec.Label)}
+	}
+	if len(pn.spec.Series) > 0 {
+		var values []namedValue
+		for _, name := range pn.spec.Series {
+			for _, v := range data.values {
+				if strings.EqualFold(v.name, name) {
+					values = append(values, v)
+				}
+			}
+		}
+		data.values = values
+	}
+	return data
+}
+
+//selectValues one value per matching series, named by label when set, otherwise by name and labels
+func selectValues(selector *helpers.Selector, data []helpers.Data, label string) []namedValue {
+	var values []namedValue
+	for _, family := range selector.Filter(data) {
+		for _, m := range family.Metric {
+			value, err := strconv.ParseFloat(m.Value, 64)
+			if err != nil {
+				helpers.LogRestFile.Warn("Failed to convert number ", m.Value, " for ", family.Name)
+				continue
+			}
+			name := family.Name + helpers.FormatLabels(m.Labels.Map())
+			if label != "" {
+				name = m.Labels.Get(label)
+			} else if len(m.Labels) == 0 {
+				name = family.Name
+			}
+			values = append(values, namedValue{name: name, key: helpers.SeriesKey(family.Name, m.Labels.Map()), value: value})
+		}
+	}
+	return values
+}
+
+//update refresh the widget from one poll, recording plotted values in the store
+func (pn *panel) update(result *pollResult, store *helpers.SeriesStore) {
+	data := pn.collect(result)
+	pn.values = data.values
+	switch w := pn.widget.(type) {
+	case *widgets.Gauge:
+		pn.updateGauge(w, data, result)
+	case *widgets.Plot, *widgets.SparklineGroup:
+		for _, v := range data.values {
+			store.Add(v.key, result.time, v.value)
+		}
+	case *widgets.BarChart:
+		w.Data, w.Labels = nil, nil
+		for _, v := range data.values {
+			w.Data = append(w.Data, v.value)
+			w.Labels = append(w.Labels, v.shortLabel())
+		}
+		w.MaxVal = floorMax(w.Data)
+	case *widgets.List:
+		rows := data.rows
+		if pn.spec.Legend {
+			rows = pn.legend(data.values)
+		} else if rows == nil {
+			for _, v := range data.values {
+				rows = append(rows, v.name+" "+pn.spec.formatValue(v.value))
+			}
+		}
+		w.Rows = rows
+		if w.SelectedRow >= len(rows) {
+			w.SelectedRow = 0
+		}
+	case *widgets.Paragraph:
+		text := data.text
+		if text == "" {
+			var lines []string
+			for _, v := range data.values {
+				lines = append(lines, v.name+": "+pn.spec.formatValue(v.value))
+			}
+			text = strings.Join(lines, "\n")
+		}
+		w.Text = text
+	}
+}
+
+//plot refresh plots and sparklines from the store, one point per column of the widget
+func (pn *panel) plot(view plotView) {
+	switch w := pn.widget.(type) {
+	case *widgets.Plot:
+		w.Title = pn.spec.Title + view.title()
+		points := w.Inner.Dx() - plotAxesWidth
+		w.Data = nil
+		for _, v := range pn.values {
+			w.Data = append(w.Data, view.line(v.key, points))
+		}
+		if len(w.Data) == 0 {
+			//the plot needs at least one line to compute its axes
+			w.Data = [][]float64{make([]float64, minPlotPoints)}
+		}
+		w.MaxVal = floorMax(w.Data...)
+	case *widgets.SparklineGroup:
+		w.Title = pn.spec.Title + view.title()
+		var sparklines []*widgets.Sparkline
+		colors := pn.spec.colors(ui.ColorGreen)
+		for i, v := range pn.values {
+			sl := widgets.NewSparkline()
+			sl.Title = v.name + " " + pn.spec.formatValue(v.value)
+			sl.Data = view.line(v.key, w.Inner.Dx())
+			sl.LineColor = colors[i%len(colors)]
+			sl.MaxVal = floorMax(sl.Data)
+			sparklines = append(sparklines, sl)
+		}
+		if len(sparklines) == 0 {
+			sparklines = append(sparklines, widgets.NewSparkline())
+		}
+		w.Sparklines = sparklines
+	}
+}
+
+func (pn *panel) updateGauge(g *widgets.Gauge, data panelData, result *pollResult) {
+	if len(data.values) == 0 {
+		g.Percent = 0
+		g.Label = "No data"
+		return
+	}
+	value := data.values[0].value
+	max := pn.maxValue
+	if pn.maxSelector != nil {
+		if maxValues := selectValues(pn.maxSelector, result.data, ""); len(maxValues) > 0 {
+			max = maxValues[0].value
+		}
+	}
+	percent := int(value)
+	if max > 0 {
+		percent = int(value / max * 100)
+	}
+	if pn.spec.Invert {
+		percent = 100 - percent
+	}
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	}
+	g.Percent = percent
+	g.Label = ""
+	if pn.spec.Format != "" {
+		g.Label = strconv.Itoa(percent) + "% " + pn.spec.formatValue(value)
+		if max > 0 {
+			g.Label += " / " + pn.spec.formatValue(max)
+		}
+	}
+}
+
+//legend one row per value in the color a plot gives the line of the same index
+func (pn *panel) legend(values []namedValue) []string {
+	if len(values) == 0 {
+		if pn.spec.Source != "" {
+			return []string{"No " + pn.spec.Source + " values"}
+		}
+		return []string{"No series match " + pn.spec.Metric}
+	}
+	colors := pn.spec.colors(ui.StandardColors...)
+	var rows []string
+	for i, v := range values {
+		rows = append(rows, "[■ "+v.name+"](fg:"+colorName(ui.SelectColor(colors, i))+") "+pn.spec.formatValue(v.value))
+	}
+	return rows
+}
+
+func (v namedValue) shortLabel() string {
+	if v.label != "" {
+		return v.label
+	}
+	return v.name
+}
+
+//floorMax explicit axis maximum for all-zero data, which termui would otherwise divide by
+func floorMax(data ...[]float64) float64 {
+	for _, row := range data {
+		for _, value := range row {
+			if value != 0 {
+				return 0
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	helpers "github.com/jfrog/frogvision/utils"
+)
+
+//poolFields the connection pool values kept per pool, in plot order
+var poolFields = []string{"Leased", "Pending", "Available", "Max"}
+
+//poolGoneAfter polls a pool can be missing before its session stats are dropped
+const poolGoneAfter = 10
+
+//poolStats session history of one remote connection pool
+type poolStats struct {
+	current helpers.ConnectionPool
+	peak    helpers.ConnectionPool
+	//peakUtilization highest utilization seen, the peaks of the single fields can come from different polls
+	peakUtilization int
+	//lastSeen time of the last poll that exported the pool
+	lastSeen time.Time
+	//missed polls in a row without the pool
+	missed int
+}
+
+//poolKey store key of one field of a pool
+func poolKey(name, field string) string {
+	return "pool/" + name + "/" + field
+}
+
+//poolValues pool fields in poolFields order
+func poolValues(pool helpers.ConnectionPool) []int {
+	return []int{pool.Leased, pool.Pending, pool.Available, pool.Max}
+}
+
+//utilization leased share of the pool max, in percent
+func utilization(pool helpers.ConnectionPool) int {
+	if pool.Max <= 0 {
+		return 0
+	}
+	return pool.Leased * 100 / pool.Max
+}
+
+//recordPools update the peaks of every pool seen in this poll and record its values in the store. Pools missing from
+//the poll keep their stats and count the missed polls, they are only dropped after poolGoneAfter polls in a row
+func recordPools(stats map[string]*poolStats, store *helpers.SeriesStore, result *pollResult) {
+	seen := make(map[string]bool, len(result.pools))
+	for _, pool := range result.pools {
+		seen[pool.Name] = true
+		s, ok := stats[pool.Name]
+		if !ok {
+			s = &poolStats{peak: helpers.ConnectionPool{Name: pool.Name}}
+			stats[pool.Name] = s
+		}
+		s.current = pool
+		s.lastSeen = result.time
+		s.missed = 0
+		for i, value := range poolValues(pool) {
+			store.Add(poolKey(pool.Name, poolFields[i]), result.time, float64(value))
+		}
+		if pool.Leased > s.peak.Leased {
+			s.peak.Leased = pool.Leased
+		}
+		if pool.Pending > s.peak.Pending {
+			s.peak.Pending = pool.Pending
+		}
+		if pool.Available > s.peak.Available {
+			s.peak.Available = pool.Available
+		}
+		if pool.Max > s.peak.Max {
+			s.peak.Max = pool.Max
+		}
+		if utilization(pool) > s.peakUtilization {
+			s.peakUtilization = utilization(pool)
+		}
+	}
+	for name := range stats {
+		if seen[name] {
+			continue
+		}
+		stats[name].missed++
+		if stats[name].missed >= poolGoneAfter {
+			delete(stats, name)
+		}
+	}
+}
+
+//poolDetail drill-down view of a single remote connection pool
+type poolDetail struct {
+	name    string
+	gauge   *widgets.Gauge
+	summary *widgets.Paragraph
+	plots   []*widgets.Plot
+	grid    *ui.Grid
+}
+
+func newPoolDetail(name string) *poolDetail {
+	pd := &poolDetail{name: name, grid: ui.NewGrid()}
+	pd.gauge = widgets.NewGauge()
+	pd.gauge.Title = name + " utilization (leased / max)"
+	pd.gauge.BarColor = ui.ColorGreen
+	pd.gauge.LabelStyle = ui.NewStyle(ui.ColorBlue)
+	pd.summary = widgets.NewParagraph()
+	pd.summary.Title = name + " (Esc to go back)"
+	colors := []ui.Color{ui.ColorGreen, ui.ColorYellow, ui.ColorBlue, ui.ColorRed}
+	for i, field := range poolFields {
+		p := widgets.NewPlot()
+		p.Title = field
+		p.Data = [][]float64{make([]float64, minPlotPoints)}
+		p.MaxVal = floorMax(p.Data...)
+		p.AxesColor = ui.ColorWhite
+		p.LineColors = []ui.Color{colors[i]}
+		p.DrawDirection = widgets.DrawLeft
+		p.HorizontalScale = 1
+		pd.plots = append(pd.plots, p)
+	}
+	pd.grid.Set(
+		ui.NewRow(0.2, ui.NewCol(0.5, pd.gauge), ui.NewCol(0.5, pd.summary)),
+		ui.NewRow(0.4, ui.NewCol(0.5, pd.plots[0]), ui.NewCol(0.5, pd.plots[1])),
+		ui.NewRow(0.4, ui.NewCol(0.5, pd.plots[2]), ui.NewCol(0.5, pd.plots[3])),
+	)
+	return pd
+}
+
+//update refresh from the pool's session stats, nil when the pool is gone. A pool missing from the last polls keeps
+//its last values and peaks below a note
+func (pd *poolDetail) update(s *poolStats, view plotView) {
+	if s == nil {
+		pd.summary.Text = "Pool " + pd.name + " is no longer exported"
+		return
+	}
+	pd.gauge.Percent = utilization(s.current)
+	if pd.gauge.Percent > 100 {
+		pd.gauge.Percent = 100
+	}
+	text := ""
+	if s.missed > 0 {
+		text = "Pool " + pd.name + " is no longer exported, last seen " + s.lastSeen.Format("15:04:05") + "\n"
+	}
+	text += fmt.Sprintf("%-12s %8s %8s\n", "", "Current", "Peak")
+	current, peak := poolValues(s.current), poolValues(s.peak)
+	for i, field := range poolFields {
+		text += fmt.Sprintf("%-12s %8d %8d\n", field+":", current[i], peak[i])
+	}
+	pd.summary.Text = text + fmt.Sprintf("%-12s %7d%% %7d%%", "Utilization:", utilization(s.current), s.peakUtilization)
+	for i, field := range poolFields {
+		line := view.line(poolKey(pd.name, field), pd.plots[i].Inner.Dx()-plotAxesWidth)
+		pd.plots[i].Title = field + view.title()
+		pd.plots[i].Data = [][]float64{line}
+		pd.plots[i].MaxVal = floorMax(line)
+	}
+}
@@ -0,0 +1,295 @@
+package commands
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//pollResult everything one poll of the metrics API produced, shared by every panel
+type pollResult struct {
+	//node the HA node polled, empty for a whole server or every node summed up
+	node        string
+	config      *config.ArtifactoryDetails
+	conf        *GraphConfiguration
+	data        []helpers.Data
+	pools       []helpers.ConnectionPool
+	rates       *helpers.RateTracker
+	time        time.Time
+	computeTime time.Time
+	lastUpdate  string
+	offset      int
+	//paused polling was paused from the keyboard after this poll
+	paused bool
+	//failure why the polls since this one failed, empty when they did not
+	failure string
+	//store history kept by the dashboard, nil without one. gcRuns every garbage collection run seen, oldest first
+	store  *helpers.SeriesStore
+	gcRuns []gcRun
+}
+
+//value first value of a family, false when it is missing or not a number
+func (result *pollResult) value(name string) (float64, bool) {
+	for i := range result.data {
+		if result.data[i].Name != name || len(result.data[i].Metric) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(result.data[i].Metric[0].Value, 64)
+		if err != nil {
+			helpers.LogRestFile.Warn("Failed to convert number ", result.data[i].Metric[0].Value, " for ", name)
+			return 0, false
+		}
+		return value, true
+	}
+	return 0, false
+}
+
+//text first value of a family as exported
+func (result *pollResult) text(name string) string {
+	for i := range result.data {
+		if result.data[i].Name == name && len(result.data[i].Metric) > 0 {
+			return result.data[i].Metric[0].Value
+		}
+	}
+	return ""
+}
+
+//key rate tracker key of the first series of a family, with its labels, e.g. the node tag. Empty when not exported
+func (result *pollResult) key(name string) string {
+	for i := range result.data {
+		if result.data[i].Name == name && len(result.data[i].Metric) > 0 {
+			return helpers.SeriesKey(name, result.data[i].Metric[0].Labels.Map())
+		}
+	}
+	return ""
+}
+
+//namedValue one value of a panel. label is a short bar chart label, the name is used when empty. key identifies the
+//series in the store
+type namedValue struct {
+	name  string
+	label string
+	key   string
+	value float64
+}
+
+//panelData what a source or selector yields for one poll. Widgets use text or rows when set, values otherwise
+type panelData struct {
+	values []namedValue
+	text   string
+	rows   []string
+}
+
+type panelSource func(result *pollResult) panelData
+
+//panelSources built-in derived values a layout panel can bind to with source:
+var panelSources = map[string]panelSource{
+	"meta":          metaSource,
+	"pool_totals":   poolTotalsSource,
+	"cpu":           cpuSource,
+	"metrics_count": metricsCountSource,
+	"gc":            gcSource,
+	"db_pool":       dbPoolSource,
+	"remote_pools":  remotePoolsSource,
+	"latency":       latencySource,
+	"heap":          heapSource,
+	"disk_forecast": forecastSource,
+	"gc_history":    gcHistorySource,
+	"all_metrics":   allMetricsSource,
+}
+
+func sourceNames() []string {
+	var names []string
+	for name := range panelSources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func metaSource(result *pollResult) panelData {
+	staleCount, oldest := staleFamilies(result)
+	now := time.Now()
+	return panelData{text: "Current time: " + now.Format("2006.01.02 15:04:05") + "\nLast updated: " + result.lastUpdate + " (" + strconv.Itoa(result.offset) + " seconds) Data Compute time:" + now.Sub(result.computeTime).String() + "\nResponse time: " + now.Sub(result.time).String() + pollingText(result) + "\nServer url: " + result.config.ServerId + nodeText(result) + staleText(staleCount, oldest, result.time) + failureText(result)}
+}
+
+//failureText the failed polls since this one, on a line of its own
+func failureText(result *pollResult) string {
+	if result.failure == "" {
+		return ""
+	}
+	return "\n[" + markupText(result.failure) + "](fg:white,bg:red)"
+}
+
+//markupText text shown within termui style markup, which square brackets would end early
+func markupText(text string) string {
+	return strings.NewReplacer("[", "(", "]", ")").Replace(text)
+}
+
+//nodeText the HA node of the poll
+func nodeText(result *pollResult) string {
+	if result.node == "" {
+		return ""
+	}
+	return " Node: " + result.node + " (" + result.config.Url + ")"
+}
+
+//pollingText the polling state as changed with the runtime keys
+func pollingText(result *pollResult) string {
+	if result.paused {
+		return " [Polling paused](fg:black,bg:yellow), every " + strconv.Itoa(result.conf.interval) + " seconds once resumed"
+	}
+	return " Polling interval: every " + strconv.Itoa(result.conf.interval) + " seconds"
+}
+
+func poolTotalsSource(result *pollResult) panelData {
+	totals := helpers.PoolTotals(result.pools)
+	return panelData{
+		values: []namedValue{{name: "Leased", value: float64(totals.Leased)}, {name: "Max", value: float64(totals.Max)}, {name: "Available", value: float64(totals.Available)}, {name: "Pending", value: float64(totals.Pending)}},
+		text:   "Leased:" + strconv.Itoa(totals.Leased) + " Max:" + strconv.Itoa(totals.Max) + " Available:" + strconv.Itoa(totals.Available) + " Pending:" + strconv.Itoa(totals.Pending),
+	}
+}
+
+func cpuSource(result *pollResult) panelData {
+	data := panelData{text: cpuText(result.rates, result.key("sys_cpu_totaltime_seconds"), result.text("sys_cpu_totaltime_seconds"), result.text("jfrt_runtime_heap_processors_total"))}
+	if cpu, ok := cpuPercent(result); ok {
+		data.values = []namedValue{{name: "Utilization", value: cpu}}
+	}
+	return data
+}
+
+func metricsCountSource(result *pollResult) panelData {
+	heapTotal := "-"
+	if value, ok := result.value("jfrt_runtime_heap_totalmemory_bytes"); ok {
+		heapTotal = helpers.ByteCountDecimal(int64(value))
+	}
+	return panelData{
+		values: []namedValue{{name: "Count", value: float64(len(result.data))}},
+		text:   "Count: " + strconv.Itoa(len(result.data)) + "\nHeap Proc: " + result.text("jfrt_runtime_heap_processors_total") + "\nHeap Total: " + heapTotal,
+	}
+}
+
+//heapSource used, committed and max heap
+func heapSource(result *pollResult) panelData {
+	h := heapModel(result)
+	if h == nil {
+		return panelData{text: "Not exported"}
+	}
+	text := "Used: " + helpers.ByteCountDecimal(int64(h.Used))
+	if h.Max > 0 {
+		text += " (" + strconv.FormatFloat(h.Percent, 'f', 1, 64) + "% of max)"
+	}
+	text += "\nCommitted: " + helpers.ByteCountDecimal(int64(h.Committed)) + "\nMax: " + helpers.ByteCountDecimal(int64(h.Max))
+	if h.Processors > 0 {
+		text += "\nProcessors: " + strconv.Itoa(h.Processors)
+	}
+	return panelData{
+		values: []namedValue{{name: "Used", value: h.Used}, {name: "Committed", value: h.Committed}, {name: "Max", value: h.Max}},
+		text:   text,
+	}
+}
+
+func gcSource(result *pollResult) panelData {
+	var lastGcRun string
+	if gc := gcModel(result); gc != nil {
+		lastGcRun = "Last GC Run:" + gc.Start.Format("2006.01.02 15:04:05") + " -> " + gc.End.Format("2006.01.02 15:04:05") + "\nType: " + gc.Type + " Status: " + gc.Status
+	}
+	cleaned, _ := result.value("jfrt_artifacts_gc_size_cleaned_bytes")
+	current, _ := result.value("jfrt_artifacts_gc_current_size_bytes")
+	return panelData{
+		values: []namedValue{{name: "Cleaned", value: cleaned}, {name: "Current", value: current}},
+		text:   lastGcRun + "\nNumber of binaries cleaned: " + result.text("jfrt_artifacts_gc_binaries_total") + " Duration: " + result.text("jfrt_artifacts_gc_duration_seconds") + "s\nCleaned up: " + helpers.ByteCountDecimal(int64(cleaned)) + " Current size: " + helpers.ByteCountDecimal(int64(current)),
+	}
+}
+
+func dbPoolSource(result *pollResult) panelData {
+	db := dbPoolModel(result)
+	if db == nil {
+		db = new(dbPool)
+	}
+	return panelData{values: []namedValue{{name: "Active", value: db.Active}, {name: "Max", value: db.Max}, {name: "Idle", value: db.Idle}, {name: "MinIdle", value: db.MinIdle}}}
+}
+
+//remotePoolsSource leased connections per pool, labelled by their index in the list rows
+func remotePoolsSource(result *pollResult) panelData {
+	var data panelData
+	for i, pool := range result.pools {
+		poolId := strconv.Itoa(i)
+		data.values = append(data.values, namedValue{name: pool.Name, label: poolId, value: float64(pool.Leased)})
+		data.rows = append(data.rows, "["+poolId+"] "+pool.Name+" Leased:"+strconv.Itoa(pool.Leased)+" Pending:"+strconv.Itoa(pool.Pending)+" Available:"+strconv.Itoa(pool.Available)+" Max:"+strconv.Itoa(pool.Max))
+	}
+	return data
+}
+
+//latencySource histogram and summary families
+func latencySource(result *pollResult) panelData {
+	var rows []string
+	for i := range result.data {
+		if result.data[i].Type == "HISTOGRAM" || result.data[i].Type == "SUMMARY" {
+			for _, m := range result.data[i].Metric {
+				rows = append(rows, latencyRow(result.data[i].Name, m))
+			}
+		}
+	}
+	if len(rows) == 0 {
+		return panelData{text: "No histogram or summary metrics exported"}
+	}
+	return panelData{rows: rows, text: strings.Join(rows, "\n")}
+}
+
+//allMetricsSource every series of the payload, histograms and summaries expanded
+func allMetricsSource(result *pollResult) panelData {
+	var data panelData
+	for _, s := range helpers.FlattenSeries(result.data) {
+		data.rows = append(data.rows, s.Name+helpers.FormatLabels(s.Labels)+" "+s.Value)
+	}
+	return data
+}
+
+//cpuText CPU utilization from the rate of the total CPU time counter under cpuKey, spread over the available processors
+func cpuText(rates *helpers.RateTracker, cpuKey string, cpuTotal string, processors string) string {
+	if cpuTotal == "" {
+		return "Not exported"
+	}
+	text := "Total: " + cpuTotal + "s"
+	cpuRate, ok := rates.Rate(cpuKey)
+	if !ok {
+		return "Utilization: measuring\n" + text
+	}
+	procs, err := strconv.Atoi(processors)
+	if err != nil || procs < 1 {
+		procs = 1
+	}
+	text = "Utilization: " + strconv.FormatFloat(cpuRate/float64(procs)*100, 'f', 1, 64) + "%\n" + text
+	if rates.Resets(cpuKey) > 0 {
+		return text + "\n[counter reset](fg:yellow)"
+	}
+	return text + "\nProcessors: " + strconv.Itoa(procs)
+}
+
+//staleText Meta statistics suffix for stale families, empty when there are none
+func staleText(staleCount int, oldest helpers.Data, now time.Time) string {
+	if staleCount == 0 {
+		return ""
+	}
+	updated, _ := oldest.UpdatedTime()
+	return " [STALE: " + strconv.Itoa(staleCount) + ", oldest " + oldest.Name + " " + helpers.FormatAge(now.Sub(updated)) + "](fg:red)"
+}
+
+//latencyRow p50/p95/p99 of a histogram or summary series
+func latencyRow(name string, m helpers.Metrics) string {
+	row := name + helpers.FormatLabels(m.Labels.Map())
+	for _, q := range []float64{0.5, 0.95, 0.99} {
+		value, ok := m.Quantile(q)
+		if ok {
+			row += " " + strconv.FormatFloat(value, 'g', 3, 64)
+		} else {
+			row += " -"
+		}
+	}
+	return row
+}
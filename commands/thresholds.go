@@ -0,0 +1,244 @@
+package commands
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+//statusLineHeight rows taken by the breach status line when the layout has thresholds
+const statusLineHeight = 1
+
+//maxBreachEvents breach events kept for the status line
+const maxBreachEvents = 50
+
+type level int
+
+const (
+	levelOK level = iota
+	levelWarn
+	levelCritical
+)
+
+func (l level) String() string {
+	switch l {
+	case levelWarn:
+		return "warning"
+	case levelCritical:
+		return "critical"
+	}
+	return "ok"
+}
+
+//color the color a widget turns at this level, fallback when ok
+func (l level) color(fallback ui.Color) ui.Color {
+	switch l {
+	case levelWarn:
+		return ui.ColorYellow
+	case levelCritical:
+		return ui.ColorRed
+	}
+	return fallback
+}
+
+//hasThresholds whether the panel sets warn or critical
+func (spec PanelSpec) hasThresholds() bool {
+	return spec.Warn != nil || spec.Critical != nil
+}
+
+//level the threshold level of value, thresholds are inclusive
+func (spec PanelSpec) level(value float64) level {
+	switch {
+	case spec.Critical != nil && value >= *spec.Critical:
+		return levelCritical
+	case spec.Warn != nil && value >= *spec.Warn:
+		return levelWarn
+	}
+	return levelOK
+}
+
+//threshold the threshold crossed at l
+func (spec PanelSpec) threshold(l level) float64 {
+	if l == levelCritical {
+		return *spec.Critical
+	}
+	return *spec.Warn
+}
+
+//overrideThresholds set warn and critical of the panels titled as in each override, Title=warn,critical. Either may be
+//left empty to drop it. Every page showing a panel of that title gets the same thresholds
+func (layout *Layout) overrideThresholds(overrides []string) error {
+	for _, override := range overrides {
+		i := strings.LastIndex(override, "=")
+		if i <= 0 {
+			return errors.New("Invalid threshold " + override + ", expected title=warn,critical, e.g. \"Current Used Storage=80,90\"")
+		}
+		title := override[:i]
+		values := strings.Split(override[i+1:], ",")
+		if len(values) != 2 {
+			return errors.New("Invalid threshold " + override + ", expected title=warn,critical, e.g. \"Current Used Storage=80,90\"")
+		}
+		var thresholds [2]*float64
+		for j, value := range values {
+			if value = strings.TrimSpace(value); value == "" {
+				continue
+			}
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return errors.New("Invalid threshold " + override + ": " + value + " is not a number")
+			}
+			thresholds[j] = &number
+		}
+		found := false
+		for _, panels := range layout.panelLists() {
+			for j := range panels {
+				if strings.EqualFold(panels[j].Title, title) {
+					panels[j].Warn, panels[j].Critical = thresholds[0], thresholds[1]
+					found = true
+				}
+			}
+		}
+		if !found {
+			return errors.New("Invalid threshold " + override + ": the layout has no panel titled " + title)
+		}
+	}
+	return layout.validate()
+}
+
+//panelLists the panels of every page, to change in place
+func (layout *Layout) panelLists() [][]PanelSpec {
+	if len(layout.Pages) == 0 {
+		return [][]PanelSpec{layout.Panels}
+	}
+	var lists [][]PanelSpec
+	for _, page := range layout.Pages {
+		lists = append(lists, page.Panels)
+	}
+	return lists
+}
+
+//checked the value thresholds apply to: the gauge percent, the highest value otherwise. False when there is none
+func (pn *panel) checked() (float64, bool) {
+	if g, ok := pn.widget.(*widgets.Gauge); ok {
+		return float64(g.Percent), len(pn.values) > 0
+	}
+	if len(pn.values) == 0 {
+		return 0, false
+	}
+	max := pn.values[0].value
+	for _, v := range pn.values[1:] {
+		if v.value > max {
+			max = v.value
+		}
+	}
+	return max, true
+}
+
+//checkThresholds recolor the panel after an update: bars turn yellow or red, and so does the border, with the title
+//highlighted when critical
+func (pn *panel) checkThresholds() {
+	if !pn.spec.hasThresholds() {
+		return
+	}
+	value, ok := pn.checked()
+	pn.level = levelOK
+	if ok {
+		pn.level = pn.spec.level(value)
+	}
+	block := pn.block()
+	block.BorderStyle.Fg = pn.level.color(ui.ColorWhite)
+	block.TitleStyle = ui.NewStyle(pn.level.color(ui.ColorWhite))
+	switch pn.level {
+	case levelWarn:
+		block.TitleStyle.Modifier = ui.ModifierBold
+	case levelCritical:
+		block.TitleStyle = ui.NewStyle(ui.ColorWhite, ui.ColorRed, ui.ModifierBold)
+	}
+	switch w := pn.widget.(type) {
+	case *widgets.Gauge:
+		w.BarColor = pn.level.color(pn.spec.colors(ui.ColorGreen)[0])
+	case *widgets.BarChart:
+		w.BarColors = pn.spec.colors(ui.ColorGreen)
+		if pn.level != levelOK {
+			w.BarColors = []ui.Color{pn.level.color(ui.ColorGreen)}
+		}
+	}
+}
+
+//breachEvent one panel crossing a threshold, or going back under it
+type breachEvent struct {
+	time  time.Time
+	title string
+	from  level
+	to    level
+	value string
+}
+
+func (e breachEvent) String() string {
+	text := e.time.Format("15:04:05") + " " + e.title + " "
+	if e.to == levelOK {
+		return text + "back to normal from " + e.from.String() + ", " + e.value
+	}
+	return text + e.to.String() + ", " + e.value
+}
+
+//recordBreaches add an event for every panel title whose level changed. Panels repeated on several pages share their
+//title and report once
+func (d *dashboard) recordBreaches(t time.Time) {
+	for _, pn := range d.panels() {
+		if !pn.spec.hasThresholds() || pn.level == d.breaches[pn.spec.Title] {
+			continue
+		}
+		value, _ := pn.checked()
+		text := pn.spec.formatValue(value)
+		if _, ok := pn.widget.(*widgets.Gauge); ok {
+			text = strconv.Itoa(int(value)) + "%"
+		}
+		if pn.level != levelOK {
+			text += " >= " + strconv.FormatFloat(pn.spec.threshold(pn.level), 'f', -1, 64)
+		}
+		d.events = append(d.events, breachEvent{time: t, title: pn.spec.Title, from: d.breaches[pn.spec.Title], to: pn.level, value: text})
+		d.breaches[pn.spec.Title] = pn.level
+	}
+	if len(d.events) > maxBreachEvents {
+		d.events = d.events[len(d.events)-maxBreachEvents:]
+	}
+	d.status.Text = d.statusText()
+}
+
+//statusText the status line: a failing poll, panels currently over a threshold, then the latest events first
+func (d *dashboard) statusText() string {
+	failure := ""
+	if d.failure != "" {
+		failure = "[" + markupText(d.failure) + "](fg:white,bg:red)"
+		if !d.thresholds {
+			return failure
+		}
+		failure += " | "
+	}
+	var warn, critical int
+	for _, l := range d.breaches {
+		switch l {
+		case levelWarn:
+			warn++
+		case levelCritical:
+			critical++
+		}
+	}
+	text := failure + "[No threshold breached](fg:green)"
+	if critical > 0 || warn > 0 {
+		text = failure + "[" + strconv.Itoa(critical) + " critical](fg:white,bg:red) [" + strconv.Itoa(warn) + " warning](fg:black,bg:yellow)"
+	}
+	var latest []string
+	for i := len(d.events) - 1; i >= 0 && len(latest) < 3; i-- {
+		latest = append(latest, d.events[i].String())
+	}
+	if len(latest) > 0 {
+		text += " | " + strings.Join(latest, " | ")
+	}
+	return text
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/jfrog/jfrog-cli-core/plugins/components"
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//maxWatchFailures polls in a row that may fail before watch gives up
+const maxWatchFailures = 5
+
+type WatchConfiguration struct {
+	interval   int
+	duration   time.Duration
+	format     string
+	output     string
+	maxSizeMB  int64
+	maxBackups int
+	selector   string
+	//increaseWindow time span the increase of counters is taken over
+	increaseWindow time.Duration
+}
+
+func getWatchConfiguration(c *components.Context, selector string) (*WatchConfiguration, error) {
+	var conf = new(WatchConfiguration)
+	var err error
+	conf.selector = selector
+
+	conf.interval, err = strconv.Atoi(c.GetStringFlagValue("interval"))
+	if err != nil || conf.interval < 1 {
+		return nil, errors.New("Invalid interval " + c.GetStringFlagValue("interval") + ", expected a number of seconds")
+	}
+	if c.GetStringFlagValue("duration") != "" {
+		conf.duration, err = time.ParseDuration(c.GetStringFlagValue("duration"))
+		if err != nil {
+			return nil, errors.New("Invalid duration " + c.GetStringFlagValue("duration") + ", expected e.g. 30m or 2h")
+		}
+	}
+
+	//json is the metrics default, stream it one series per line
+	conf.format = c.GetStringFlagValue("format")
+	if conf.format == "json" {
+		conf.format = "ndjson"
+	}
+	if conf.format != "ndjson" && conf.format != "csv" {
+		return nil, errors.New("Unsupported watch format " + conf.format + ", expected ndjson or csv")
+	}
+
+	conf.increaseWindow, err = time.ParseDuration(c.GetStringFlagValue("increase-window"))
+	if err != nil || conf.increaseWindow < time.Second*time.Duration(conf.interval) {
+		return nil, errors.New("Invalid increase-window " + c.GetStringFlagValue("increase-window") + ", expected a duration of at least the interval, e.g. 5m")
+	}
+
+	conf.output = c.GetStringFlagValue("output")
+	conf.maxSizeMB, err = strconv.ParseInt(c.GetStringFlagValue("max-size"), 10, 64)
+	if err != nil {
+		return nil, errors.New("Invalid max-size " + c.GetStringFlagValue("max-size"))
+	}
+	conf.maxBackups, err = strconv.Atoi(c.GetStringFlagValue("max-files"))
+	if err != nil {
+		return nil, errors.New("Invalid max-files " + c.GetStringFlagValue("max-files"))
+	}
+	return conf, nil
+}
+
+//metricsWatch poll metrics on an interval and stream timestamped samples until the duration elapses or on interrupt
+func metricsWatch(config *config.ArtifactoryDetails, conf *WatchConfiguration) error {
+	var selector *helpers.Selector
+	if conf.selector != "" {
+		var err error
+		selector, err = helpers.ParseSelector(conf.selector)
+		if err != nil {
+			return err
+		}
+	}
+
+	var header []byte
+	if conf.format == "csv" {
+		header = helpers.SeriesCSVHeader
+	}
+	var out io.Writer = os.Stdout
+	if conf.output != "" {
+		rf, err := helpers.NewRotatingFile(conf.output, conf.maxSizeMB*1000*1000, conf.maxBackups, header)
+		if err != nil {
+			return errors.New(err.Error() + " at " + string(helpers.Trace().Fn) + " on line " + strconv.Itoa(helpers.Trace().Line))
+		}
+		defer rf.Close()
+		out = rf
+	} else if header != nil {
+		os.Stdout.Write(header)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	return runWatch(artifactorySource{config: config}, conf, selector, time.Second*time.Duration(conf.interval), out, os.Stderr, interrupt)
+}
+
+//runWatch poll source every interval until the duration elapses or an interrupt. Failed polls are reported on errOut
+//and skipped, watching through transient failures is usually when the data matters most, but maxWatchFailures in a
+//row end the watch with an error
+func runWatch(source metricsSource, conf *WatchConfiguration, selector *helpers.Selector, interval time.Duration, out io.Writer, errOut io.Writer, interrupt <-chan os.Signal) error {
+	var deadline <-chan time.Time
+	if conf.duration > 0 {
+		deadline = time.After(conf.duration)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	//rates only need the last two polls, the increase the whole window
+	rates := helpers.NewRateTracker(conf.increaseWindow)
+
+	failures := 0
+	for {
+		now := time.Now()
+		data, _, _, err := source.fetch(0, conf.interval)
+		if err != nil {
+			failures++
+			helpers.LogRestFile.Warn("watch poll failed: ", err)
+			fmt.Fprintln(errOut, "metrics watch: poll failed ("+strconv.Itoa(failures)+" in a row): "+err.Error())
+			if failures >= maxWatchFailures {
+				return errors.New("Giving up after " + strconv.Itoa(failures) + " failed polls in a row: " + err.Error())
+			}
+		} else {
+			failures = 0
+			if err := watchPoll(data, now, selector, rates, conf.format, out); err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			return nil
+		case <-interrupt:
+			return nil
+		}
+	}
+}
+
+//watchPoll write the series of one poll, with the rate of counters since the previous poll
+func watchPoll(data []helpers.Data, now time.Time, selector *helpers.Selector, rates *helpers.RateTracker, format string, out io.Writer) error {
+	pollTime := now.Format(time.RFC3339)
+	if selector != nil {
+		data = selector.Filter(data)
+	}
+	rates.Observe(data, now)
+	series := helpers.FlattenSeries(data)
+	for i := range series {
+		series[i].Time = pollTime
+		if series[i].Type == "COUNTER" || strings.HasSuffix(series[i].Name, "_count") || strings.HasSuffix(series[i].Name, "_sum") {
+			key := helpers.SeriesKey(series[i].Name, series[i].Labels)
+			if rate, ok := rates.Rate(key); ok {
+				series[i].Rate = strconv.FormatFloat(rate, 'g', 6, 64)
+			}
+			if increase, ok := rates.Increase(key); ok {
+				series[i].Increase = strconv.FormatFloat(increase, 'g', 6, 64)
+			}
+		}
+	}
+
+	//buffer the whole poll so a file rotation never splits it
+	var buf bytes.Buffer
+	var err error
+	if format == "csv" {
+		err = helpers.WriteSeriesCSV(&buf, series)
+	} else {
+		err = helpers.WriteNDJSON(&buf, series)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	helpers "github.com/jfrog/frogvision/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWatchNDJSON(t *testing.T) {
+	source := new(fakeSource)
+	conf := &WatchConfiguration{interval: 1, increaseWindow: time.Minute, duration: 50 * time.Millisecond, format: "ndjson"}
+	var out, errOut bytes.Buffer
+	assert.NoError(t, runWatch(source, conf, nil, 10*time.Millisecond, &out, &errOut, make(chan os.Signal)))
+	assert.Empty(t, errOut.String())
+	//the duration stops the watch after a few polls
+	calls := atomic.LoadInt32(&source.calls)
+	assert.True(t, calls > 1 && calls < 10, calls)
+
+	times := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var series helpers.Series
+		if assert.NoError(t, json.Unmarshal([]byte(line), &series), line) {
+			assert.NotEmpty(t, series.Name)
+			times[series.Time] = true
+		}
+	}
+	assert.NotEmpty(t, times)
+}
+
+func TestRunWatchCSV(t *testing.T) {
+	selector, err := helpers.ParseSelector(`jfrt_http_connections_leased_total{pool="npm-remote"}`)
+	assert.NoError(t, err)
+	conf := &WatchConfiguration{interval: 1, increaseWindow: time.Minute, format: "csv"}
+	interrupt := make(chan os.Signal, 1)
+	interrupt <- os.Interrupt
+	var out, errOut bytes.Buffer
+	assert.NoError(t, runWatch(new(fakeSource), conf, selector, time.Hour, &out, &errOut, interrupt))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if assert.Len(t, lines, 1) {
+		assert.Contains(t, lines[0], ",jfrt_http_connections_leased_total,")
+		assert.Contains(t, lines[0], ",1,gauge,")
+	}
+}
+
+func TestRunWatchFailures(t *testing.T) {
+	source := &fakeSource{err: errors.New("connection refused")}
+	conf := &WatchConfiguration{interval: 1, increaseWindow: time.Minute, format: "ndjson"}
+	var out, errOut bytes.Buffer
+	err := runWatch(source, conf, nil, time.Millisecond, &out, &errOut, make(chan os.Signal))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "5 failed polls in a row: connection refused")
+	}
+	assert.Equal(t, int32(maxWatchFailures), atomic.LoadInt32(&source.calls))
+	assert.Equal(t, maxWatchFailures, strings.Count(errOut.String(), "poll failed"))
+	assert.Empty(t, out.String())
+}
+
+//TestWatchPollIncrease counters get their rate since the previous poll and their increase over the window, across a
+//reset
+func TestWatchPollIncrease(t *testing.T) {
+	rates := helpers.NewRateTracker(time.Minute)
+	start := time.Date(2020, 12, 6, 20, 50, 30, 0, time.UTC)
+	var out bytes.Buffer
+	for i, value := range []string{"100", "130", "10"} {
+		data := []helpers.Data{{Name: "jfrt_artifacts_gc_runs_total", Type: "COUNTER", Metric: []helpers.Metrics{{Value: value}}}}
+		assert.NoError(t, watchPoll(data, start.Add(time.Duration(i)*10*time.Second), nil, rates, "csv", &out))
+	}
+	assert.Equal(t, "2020-12-06T20:50:30Z,jfrt_artifacts_gc_runs_total,,100,counter,,,\n"+
+		"2020-12-06T20:50:40Z,jfrt_artifacts_gc_runs_total,,130,counter,,3,30\n"+
+		"2020-12-06T20:50:50Z,jfrt_artifacts_gc_runs_total,,10,counter,,1,40\n", out.String())
+}
@@ -38,5 +38,6 @@ func getCommands() []components.Command {
 		commands.GetHelloCommand(),
 		commands.GetGraphCommand(),
 		commands.GetMetricsCommand(),
+		commands.GetForecastCommand(),
 	}
 }
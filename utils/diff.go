@@ -0,0 +1,111 @@
+package helpers
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+//SeriesRef identifies one series in a diff
+type SeriesRef struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+//SeriesDelta value change of a series present on both sides
+type SeriesDelta struct {
+	SeriesRef
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Delta  float64 `json:"delta"`
+	//Percent change relative to Before, +/-Inf when Before is 0
+	Percent float64 `json:"-"`
+	//PercentText Percent for display and JSON, which has no Inf
+	PercentText string `json:"percent"`
+}
+
+//MetricsDiff what changed between two metric captures
+type MetricsDiff struct {
+	AddedFamilies   []string      `json:"added_families"`
+	RemovedFamilies []string      `json:"removed_families"`
+	AddedSeries     []SeriesRef   `json:"added_series"`
+	RemovedSeries   []SeriesRef   `json:"removed_series"`
+	Changed         []SeriesDelta `json:"changed"`
+}
+
+//SeriesKey unique identity of a series, name plus sorted labels
+func SeriesKey(name string, labels map[string]string) string {
+	return name + FormatLabels(labels)
+}
+
+//DiffMetrics compare capture a (before) to b (after). Changed series are sorted by magnitude of the percentage change,
+//series appearing from zero first, then by absolute delta
+func DiffMetrics(a, b []Data) MetricsDiff {
+	var diff MetricsDiff
+
+	familiesA, familiesB := make(map[string]bool), make(map[string]bool)
+	seriesA := make(map[string]Series)
+	for _, s := range FlattenSeries(a) {
+		familiesA[s.Name] = true
+		seriesA[SeriesKey(s.Name, s.Labels)] = s
+	}
+	seriesB := make(map[string]bool)
+	for _, s := range FlattenSeries(b) {
+		familiesB[s.Name] = true
+		key := SeriesKey(s.Name, s.Labels)
+		seriesB[key] = true
+
+		before, ok := seriesA[key]
+		if !ok {
+			//series of a brand new family are already covered by AddedFamilies
+			if familiesA[s.Name] {
+				diff.AddedSeries = append(diff.AddedSeries, SeriesRef{s.Name, s.Labels})
+			}
+			continue
+		}
+		beforeValue, errBefore := strconv.ParseFloat(before.Value, 64)
+		afterValue, errAfter := strconv.ParseFloat(s.Value, 64)
+		if errBefore != nil || errAfter != nil || beforeValue == afterValue {
+			continue
+		}
+		delta := SeriesDelta{SeriesRef: SeriesRef{s.Name, s.Labels}, Before: beforeValue, After: afterValue, Delta: afterValue - beforeValue}
+		if beforeValue == 0 {
+			delta.Percent = math.Inf(int(math.Copysign(1, delta.Delta)))
+			delta.PercentText = "new"
+		} else {
+			delta.Percent = delta.Delta / math.Abs(beforeValue) * 100
+			delta.PercentText = strconv.FormatFloat(delta.Percent, 'f', 2, 64) + "%"
+			if delta.Percent > 0 {
+				delta.PercentText = "+" + delta.PercentText
+			}
+		}
+		diff.Changed = append(diff.Changed, delta)
+	}
+	for _, s := range FlattenSeries(a) {
+		if familiesB[s.Name] && !seriesB[SeriesKey(s.Name, s.Labels)] {
+			diff.RemovedSeries = append(diff.RemovedSeries, SeriesRef{s.Name, s.Labels})
+		}
+	}
+
+	for name := range familiesB {
+		if !familiesA[name] {
+			diff.AddedFamilies = append(diff.AddedFamilies, name)
+		}
+	}
+	for name := range familiesA {
+		if !familiesB[name] {
+			diff.RemovedFamilies = append(diff.RemovedFamilies, name)
+		}
+	}
+	sort.Strings(diff.AddedFamilies)
+	sort.Strings(diff.RemovedFamilies)
+
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		pi, pj := math.Abs(diff.Changed[i].Percent), math.Abs(diff.Changed[j].Percent)
+		if pi != pj {
+			return pi > pj
+		}
+		return math.Abs(diff.Changed[i].Delta) > math.Abs(diff.Changed[j].Delta)
+	})
+	return diff
+}
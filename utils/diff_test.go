@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const diffBefore = `# HELP jfrt_db_connections_active_total Total Active Connections
+# TYPE jfrt_db_connections_active_total gauge
+jfrt_db_connections_active_total 4 1607287853275
+# HELP app_disk_free_bytes Free Space
+# TYPE app_disk_free_bytes gauge
+app_disk_free_bytes 1000 1607287853275
+# HELP jfrt_artifacts_gc_binaries_total Number of binaries removed by Garbage Collection
+# TYPE jfrt_artifacts_gc_binaries_total counter
+jfrt_artifacts_gc_binaries_total{status="COMPLETED",type="FULL"} 10 1607287853275
+`
+
+const diffAfter = `# HELP jfrt_db_connections_active_total Total Active Connections
+# TYPE jfrt_db_connections_active_total gauge
+jfrt_db_connections_active_total 5 1607287863275
+# HELP app_disk_free_bytes Free Space
+# TYPE app_disk_free_bytes gauge
+app_disk_free_bytes 900 1607287863275
+# HELP jfrt_artifacts_gc_binaries_total Number of binaries removed by Garbage Collection
+# TYPE jfrt_artifacts_gc_binaries_total counter
+jfrt_artifacts_gc_binaries_total{status="FAILED",type="FULL"} 0 1607287863275
+# HELP sys_cpu_totaltime_seconds Total CPU Time
+# TYPE sys_cpu_totaltime_seconds counter
+sys_cpu_totaltime_seconds 12 1607287863275
+`
+
+func parseTestMetrics(t *testing.T, text string) []Data {
+	jsonText, err := MetricsTextToJSON([]byte(text), false)
+	assert.NoError(t, err)
+	data, err := ParseMetricsJSON(jsonText)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestDiffMetrics(t *testing.T) {
+	diff := DiffMetrics(parseTestMetrics(t, diffBefore), parseTestMetrics(t, diffAfter))
+
+	assert.Equal(t, []string{"sys_cpu_totaltime_seconds"}, diff.AddedFamilies)
+	assert.Empty(t, diff.RemovedFamilies)
+	assert.Len(t, diff.AddedSeries, 1)
+	assert.Equal(t, "FAILED", diff.AddedSeries[0].Labels["status"])
+	assert.Len(t, diff.RemovedSeries, 1)
+	assert.Equal(t, "COMPLETED", diff.RemovedSeries[0].Labels["status"])
+
+	//25% on the DB pool outranks -10% on disk even though the disk delta is larger
+	assert.Len(t, diff.Changed, 2)
+	assert.Equal(t, "jfrt_db_connections_active_total", diff.Changed[0].Name)
+	assert.Equal(t, "+25.00%", diff.Changed[0].PercentText)
+	assert.Equal(t, "app_disk_free_bytes", diff.Changed[1].Name)
+	assert.Equal(t, float64(-100), diff.Changed[1].Delta)
+	assert.Equal(t, "-10.00%", diff.Changed[1].PercentText)
+}
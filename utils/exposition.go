@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"strings"
+)
+
+type expositionFamily struct {
+	help    string
+	typ     string
+	updated string
+	samples []string
+}
+
+//mergeFamilies Artifactory repeats the HELP/TYPE/UPDATED block of a family for every label set (one per remote
+//connection pool for jfrt_http_connections_*), which the Prometheus text parser rejects. Regroup the exposition so each
+//family is declared once followed by all of its samples, keeping the real metric names and labels. Also returns the
+//UPDATED epoch of each family, which the text parser ignores
+func mergeFamilies(text string) (string, map[string]string) {
+	families := make(map[string]*expositionFamily)
+	var order []string
+	family := func(name string) *expositionFamily {
+		f, ok := families[name]
+		if !ok {
+			f = new(expositionFamily)
+			families[name] = f
+			order = append(order, name)
+		}
+		return f
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.SplitN(strings.TrimSpace(line[1:]), " ", 3)
+			if len(fields) < 2 {
+				continue
+			}
+			value := ""
+			if len(fields) == 3 {
+				value = fields[2]
+			}
+			switch fields[0] {
+			case "HELP":
+				if f := family(fields[1]); f.help == "" {
+					f.help = value
+				}
+			case "TYPE":
+				if f := family(fields[1]); f.typ == "" {
+					f.typ = value
+				}
+			case "UPDATED":
+				//repeated blocks each carry their own timestamp, keep the most recent one
+				if f := family(fields[1]); f.updated == "" || StringToInt64(value) > StringToInt64(f.updated) {
+					f.updated = value
+				}
+			}
+			continue
+		}
+		f := family(sampleFamily(line, families))
+		f.samples = append(f.samples, line)
+	}
+
+	updated := make(map[string]string)
+	var b strings.Builder
+	for _, name := range order {
+		f := families[name]
+		if f.help != "" {
+			b.WriteString("# HELP " + name + " " + f.help + "\n")
+		}
+		if f.updated != "" {
+			b.WriteString("# UPDATED " + name + " " + f.updated + "\n")
+			updated[name] = f.updated
+		}
+		if f.typ != "" {
+			b.WriteString("# TYPE " + name + " " + f.typ + "\n")
+		}
+		for _, sample := range f.samples {
+			b.WriteString(sample + "\n")
+		}
+	}
+	return b.String(), updated
+}
+
+//sampleFamily family a sample line belongs to, mapping _bucket/_sum/_count back to a declared histogram or summary
+func sampleFamily(line string, families map[string]*expositionFamily) string {
+	name := line
+	if i := strings.IndexAny(line, "{ \t"); i >= 0 {
+		name = line[:i]
+	}
+	if _, ok := families[name]; ok {
+		return name
+	}
+	for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+		base := strings.TrimSuffix(name, suffix)
+		if f, ok := families[base]; ok && base != name && (f.typ == "histogram" || f.typ == "summary") {
+			return base
+		}
+	}
+	return name
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import "strings"
+
+//FlagValues every value given to a repeatable flag of command, in order. The plugin framework keeps only the last one,
+//so they are read from the raw command line, from the arguments the framework parses flags from: after the first one
+//naming command or one of its aliases, from the first flag up to the first argument that is neither a flag nor a flag
+//value, or up to --. Flags are given as --name value, --name=value or with a single dash, boolFlags take no value
+func FlagValues(args []string, command []string, name string, boolFlags []string) []string {
+	start := 0
+	for i := 1; i < len(args) && start == 0; i++ {
+		if containsString(command, args[i]) {
+			start = i + 1
+		}
+	}
+	if start == 0 {
+		return nil
+	}
+	var values []string
+	flags := false
+	for i := start; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		//arguments before the first flag are positional, the first one after the flags ends them
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			if flags {
+				break
+			}
+			continue
+		}
+		flags = true
+		flag := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		value, hasValue := "", false
+		if j := strings.Index(flag, "="); j >= 0 {
+			flag, value, hasValue = flag[:j], flag[j+1:], true
+		} else if !containsString(boolFlags, flag) {
+			if i+1 == len(args) {
+				break
+			}
+			//like the framework, the next argument is the value even when it starts with a dash
+			i++
+			value, hasValue = args[i], true
+		}
+		if flag == name && hasValue {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlagValues(t *testing.T) {
+	graph := []string{"graph", "g"}
+	args := []string{"jfrog", "frogvision", "graph", "--metric", "a", "--interval=5", "-metric=b{x=\"1\",y=\"2\"}", "--metrics", "c", "--", "--metric", "d"}
+	assert.Equal(t, []string{"a", "b{x=\"1\",y=\"2\"}"}, FlagValues(args, graph, "metric", nil))
+	assert.Equal(t, []string{"5"}, FlagValues(args, graph, "interval", nil))
+	assert.Empty(t, FlagValues(args, graph, "layout", nil))
+	assert.Empty(t, FlagValues([]string{"frogvision", "graph", "--metric"}, graph, "metric", nil))
+
+	//only the flags of the command count, by name or alias
+	args = []string{"frogvision", "--metric", "x", "g", "--metric", "a"}
+	assert.Equal(t, []string{"a"}, FlagValues(args, graph, "metric", nil))
+	assert.Empty(t, FlagValues(args, []string{"forecast"}, "metric", nil))
+	//nor the program name
+	assert.Empty(t, FlagValues([]string{"graph", "--metric", "a"}, graph, "metric", nil))
+
+	//the first positional argument after the flags ends them, bool flags take no value
+	args = []string{"frogvision", "graph", "--headless", "--metric", "a", "--discover-nodes", "extra", "--metric", "b"}
+	assert.Equal(t, []string{"a"}, FlagValues(args, graph, "metric", []string{"headless", "discover-nodes"}))
+	args = []string{"frogvision", "graph", "--headless=true", "--metric", "a", "extra", "--metric", "b"}
+	assert.Equal(t, []string{"a"}, FlagValues(args, graph, "metric", []string{"headless"}))
+
+	//positional arguments before the first flag are skipped, as the framework moves them after the flags
+	args = []string{"frogvision", "forecast", "now", "--history", "a.ndjson", "--history=b.csv"}
+	assert.Equal(t, []string{"a.ndjson", "b.csv"}, FlagValues(args, []string{"forecast"}, "history", nil))
+
+	//values that look like flags are still values
+	args = []string{"frogvision", "graph", "--metric", "--interval", "--metric", "-"}
+	assert.Equal(t, []string{"--interval", "-"}, FlagValues(args, graph, "metric", nil))
+}
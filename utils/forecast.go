@@ -0,0 +1,122 @@
+package helpers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+//Trend least squares line through a series, Slope in units per second from Origin
+type Trend struct {
+	Slope     float64
+	Intercept float64
+	Origin    time.Time
+	//Span time between the first and last sample fitted
+	Span    time.Duration
+	Samples int
+}
+
+//FitTrend fit a line through the samples, false with fewer than two samples or no time between them
+func FitTrend(samples []Sample) (Trend, bool) {
+	if len(samples) < 2 {
+		return Trend{}, false
+	}
+	origin := samples[0].Time
+	var sumX, sumY, sumXX, sumXY float64
+	for _, s := range samples {
+		x := s.Time.Sub(origin).Seconds()
+		sumX += x
+		sumY += s.Value
+		sumXX += x * x
+		sumXY += x * s.Value
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return Trend{}, false
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	return Trend{
+		Slope:     slope,
+		Intercept: (sumY - slope*sumX) / n,
+		Origin:    origin,
+		Span:      samples[len(samples)-1].Time.Sub(origin),
+		Samples:   len(samples),
+	}, true
+}
+
+//At value of the trend line at t
+func (t Trend) At(at time.Time) float64 {
+	return t.Intercept + t.Slope*at.Sub(t.Origin).Seconds()
+}
+
+//When time the trend line reaches value, false when it never does going forward from after
+func (t Trend) When(value float64, after time.Time) (time.Time, bool) {
+	if t.Slope == 0 {
+		return time.Time{}, false
+	}
+	seconds := (value - t.Intercept) / t.Slope
+	when := t.Origin.Add(time.Duration(seconds * float64(time.Second)))
+	if when.Before(after) {
+		return time.Time{}, false
+	}
+	return when, true
+}
+
+//ReadRecording samples of the named series from a metrics watch recording, ndjson or csv with its header. Series
+//with labels are skipped, recordings of several servers are not told apart
+func ReadRecording(r io.Reader, names ...string) (map[string][]Sample, error) {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+	samples := make(map[string][]Sample)
+	add := func(timeText, name, labels, valueText string) {
+		if !wanted[name] || labels != "" {
+			return
+		}
+		t, err := time.Parse(time.RFC3339, timeText)
+		if err != nil {
+			return
+		}
+		value, err := strconv.ParseFloat(valueText, 64)
+		if err != nil {
+			return
+		}
+		samples[name] = append(samples[name], Sample{t, value})
+	}
+
+	reader := bufio.NewReader(r)
+	head, _ := reader.Peek(len(seriesCSVColumns))
+	if bytes.Equal(head, seriesCSVColumns) {
+		cr := csv.NewReader(reader)
+		cr.FieldsPerRecord = -1
+		records, err := cr.ReadAll()
+		if err != nil {
+			return nil, errors.New("Invalid csv recording: " + err.Error())
+		}
+		for _, record := range records[1:] {
+			if len(record) >= 4 {
+				add(record[0], record[1], record[2], record[3])
+			}
+		}
+		return samples, nil
+	}
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var s Series
+		if err := decoder.Decode(&s); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.New("Invalid ndjson recording: " + err.Error())
+		}
+		add(s.Time, s.Name, FormatLabels(s.Labels), s.Value)
+	}
+	return samples, nil
+}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFitTrend(t *testing.T) {
+	start := time.Date(2020, 12, 6, 20, 0, 0, 0, time.UTC)
+	_, ok := FitTrend([]Sample{{start, 1}})
+	assert.False(t, ok)
+	_, ok = FitTrend([]Sample{{start, 1}, {start, 2}})
+	assert.False(t, ok)
+
+	trend, ok := FitTrend([]Sample{{start, 100}, {start.Add(time.Minute), 160}, {start.Add(2 * time.Minute), 220}})
+	assert.True(t, ok)
+	assert.InDelta(t, 1, trend.Slope, 1e-9)
+	assert.InDelta(t, 100, trend.Intercept, 1e-9)
+	assert.Equal(t, 2*time.Minute, trend.Span)
+	assert.InDelta(t, 400, trend.At(start.Add(5*time.Minute)), 1e-9)
+	when, ok := trend.When(1000, start)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(15*time.Minute), when)
+	_, ok = trend.When(50, start)
+	assert.False(t, ok)
+
+	flat, _ := FitTrend([]Sample{{start, 5}, {start.Add(time.Minute), 5}})
+	_, ok = flat.When(10, start)
+	assert.False(t, ok)
+}
+
+func TestReadRecording(t *testing.T) {
+	var ndjson bytes.Buffer
+	assert.NoError(t, WriteNDJSON(&ndjson, []Series{
+		{Time: "2020-12-06T20:00:00Z", Name: "app_disk_free_bytes", Value: "375"},
+		{Time: "2020-12-06T20:00:00Z", Name: "jfrt_http_connections_leased_total", Labels: map[string]string{"pool": "a"}, Value: "2"},
+		{Time: "2020-12-06T20:00:10Z", Name: "app_disk_free_bytes", Value: "370"},
+	}))
+	samples, err := ReadRecording(&ndjson, "app_disk_free_bytes", "jfrt_http_connections_leased_total")
+	assert.NoError(t, err)
+	assert.Equal(t, []Sample{{time.Date(2020, 12, 6, 20, 0, 0, 0, time.UTC), 375}, {time.Date(2020, 12, 6, 20, 0, 10, 0, time.UTC), 370}}, samples["app_disk_free_bytes"])
+	assert.Empty(t, samples["jfrt_http_connections_leased_total"])
+
+	csv := bytes.NewBuffer(SeriesCSVHeader)
+	assert.NoError(t, WriteSeriesCSV(csv, []Series{{Time: "2020-12-06T20:00:00Z", Name: "app_disk_total_bytes", Value: "1000", Type: "GAUGE"}}))
+	samples, err = ReadRecording(csv, "app_disk_total_bytes")
+	assert.NoError(t, err)
+	assert.Len(t, samples["app_disk_total_bytes"], 1)
+
+	//recorded before the increase column
+	samples, err = ReadRecording(strings.NewReader("time,name,labels,value,type,timestamp_ms,rate\n2020-12-06T20:00:00Z,app_disk_total_bytes,,1000,gauge,,\n"), "app_disk_total_bytes")
+	assert.NoError(t, err)
+	assert.Len(t, samples["app_disk_total_bytes"], 1)
+
+	_, err = ReadRecording(strings.NewReader("{not json"), "app_disk_free_bytes")
+	assert.Error(t, err)
+}
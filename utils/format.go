@@ -0,0 +1,221 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+//OutputFormats formats understood by WriteMetrics
+var OutputFormats = []string{"json", "table", "csv", "yaml", "ndjson", "openmetrics"}
+
+//Series single labelled sample of a family, flattened for line based outputs
+type Series struct {
+	Time        string            `json:"time,omitempty" yaml:"time,omitempty"`
+	Name        string            `json:"name" yaml:"name"`
+	Type        string            `json:"type" yaml:"type"`
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Value       string            `json:"value" yaml:"value"`
+	TimestampMs string            `json:"timestamp_ms,omitempty" yaml:"timestamp_ms,omitempty"`
+	//Rate per second rate of counters in streamed output, once two polls have been seen. Increase their increase over
+	//the window the stream keeps, across counter resets
+	Rate     string `json:"rate,omitempty" yaml:"rate,omitempty"`
+	Increase string `json:"increase,omitempty" yaml:"increase,omitempty"`
+}
+
+//FlattenSeries one Series per metric of every family, in payload order. Histograms and summaries are expanded the
+//way the exposition format writes them: _bucket{le=...} / {quantile=...}, then _sum and _count
+func FlattenSeries(data []Data) []Series {
+	var series []Series
+	for i := range data {
+		series = append(series, familySeries(data[i])...)
+	}
+	return series
+}
+
+func familySeries(family Data) []Series {
+	var series []Series
+	for _, m := range family.Metric {
+		base := Series{Name: family.Name, Type: family.Type, TimestampMs: m.TimestampMs}
+		if !m.IsDistribution() {
+			base.Labels = m.Labels.Map()
+			base.Value = m.Value
+			series = append(series, base)
+			continue
+		}
+
+		if m.Buckets != nil {
+			for _, b := range m.SortedBuckets() {
+				s := base
+				s.Name = family.Name + "_bucket"
+				s.Labels = m.Labels.Map()
+				s.Labels["le"] = strconv.FormatFloat(b.UpperBound, 'g', -1, 64)
+				s.Value = strconv.FormatFloat(b.Count, 'g', -1, 64)
+				series = append(series, s)
+			}
+		}
+		quantiles := make([]string, 0, len(m.Quantiles))
+		for q := range m.Quantiles {
+			quantiles = append(quantiles, q)
+		}
+		sort.Strings(quantiles)
+		for _, q := range quantiles {
+			s := base
+			s.Labels = m.Labels.Map()
+			s.Labels["quantile"] = q
+			s.Value = m.Quantiles[q]
+			series = append(series, s)
+		}
+
+		sum, count := base, base
+		sum.Name, sum.Labels, sum.Value = family.Name+"_sum", m.Labels.Map(), m.Sum
+		count.Name, count.Labels, count.Value = family.Name+"_count", m.Labels.Map(), m.Count
+		series = append(series, sum, count)
+	}
+	return series
+}
+
+//FormatLabels render labels as {a="x",b="y"} sorted by name, empty string when there are none
+func FormatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=\"" + labelValueEscaper.Replace(labels[name]) + "\""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n")
+
+//WriteMetrics write the parsed metrics to w in one of OutputFormats. pretty only applies to json
+func WriteMetrics(w io.Writer, data []Data, format string, pretty bool) error {
+	switch format {
+	case "", "json":
+		var jsonText []byte
+		var err error
+		if pretty {
+			jsonText, err = json.MarshalIndent(data, "", "    ")
+		} else {
+			jsonText, err = json.Marshal(data)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(jsonText))
+		return err
+	case "table":
+		return writeTable(w, data)
+	case "csv":
+		return writeCSV(w, data)
+	case "yaml":
+		yamlText, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(yamlText)
+		return err
+	case "ndjson":
+		return WriteNDJSON(w, FlattenSeries(data))
+	case "openmetrics":
+		return writeOpenMetrics(w, data)
+	}
+	return errors.New("Unknown format " + format + ", expected one of: " + strings.Join(OutputFormats, ", "))
+}
+
+func writeTable(w io.Writer, data []Data) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tLABELS\tVALUE\tTYPE")
+	for _, s := range FlattenSeries(data) {
+		fmt.Fprintln(tw, s.Name+"\t"+FormatLabels(s.Labels)+"\t"+s.Value+"\t"+strings.ToLower(s.Type))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, data []Data) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "labels", "value", "type", "timestamp_ms"})
+	for _, s := range FlattenSeries(data) {
+		cw.Write([]string{s.Name, FormatLabels(s.Labels), s.Value, strings.ToLower(s.Type), s.TimestampMs})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//SeriesCSVHeader header for WriteSeriesCSV
+var SeriesCSVHeader = []byte("time,name,labels,value,type,timestamp_ms,rate,increase\n")
+
+//seriesCSVColumns the columns every WriteSeriesCSV header has started with, recordings of older versions included
+var seriesCSVColumns = []byte("time,name,labels,value,")
+
+//WriteSeriesCSV timestamped csv rows, without header, for streamed samples
+func WriteSeriesCSV(w io.Writer, series []Series) error {
+	cw := csv.NewWriter(w)
+	for _, s := range series {
+		cw.Write([]string{s.Time, s.Name, FormatLabels(s.Labels), s.Value, strings.ToLower(s.Type), s.TimestampMs, s.Rate, s.Increase})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//WriteNDJSON one JSON object per series per line
+func WriteNDJSON(w io.Writer, series []Series) error {
+	encoder := json.NewEncoder(w)
+	for i := range series {
+		if err := encoder.Encode(series[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeOpenMetrics(w io.Writer, data []Data) error {
+	var b strings.Builder
+	for i := range data {
+		metricType := strings.ToLower(data[i].Type)
+		//OpenMetrics has no untyped, its name for the same thing is unknown
+		if metricType == "untyped" {
+			metricType = "unknown"
+		}
+		//a counter family is named without _total, its samples with it
+		family := data[i].Name
+		if metricType == "counter" {
+			family = strings.TrimSuffix(family, "_total")
+		}
+		if data[i].Help != "" {
+			b.WriteString("# HELP " + family + " " + labelValueEscaper.Replace(data[i].Help) + "\n")
+		}
+		if metricType != "" {
+			b.WriteString("# TYPE " + family + " " + metricType + "\n")
+		}
+		for _, s := range familySeries(data[i]) {
+			name := s.Name
+			if metricType == "counter" {
+				name = family + "_total"
+			}
+			b.WriteString(name + FormatLabels(s.Labels) + " " + s.Value)
+			//OpenMetrics timestamps are seconds, Artifactory sends milliseconds
+			if ms, err := strconv.ParseInt(s.TimestampMs, 10, 64); err == nil {
+				b.WriteString(" " + strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64))
+			}
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("# EOF\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,68 @@
+package helpers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testFormatData() []Data {
+	return []Data{
+		{Name: "jfrt_http_connections_max_total", Help: "Max Connections", Type: "GAUGE", Metric: []Metrics{
+			{Value: "50", TimestampMs: "1607287853275", Labels: LabelSet{"pool": "maven-remote", "max": "50"}},
+		}},
+		{Name: "app_disk_free_bytes", Help: "Free Space", Type: "GAUGE", Metric: []Metrics{{Value: "1.5e+10"}}},
+	}
+}
+
+func TestWriteMetricsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMetrics(&buf, testFormatData(), "csv", false))
+	assert.Equal(t, "name,labels,value,type,timestamp_ms\n"+
+		"jfrt_http_connections_max_total,\"{max=\"\"50\"\",pool=\"\"maven-remote\"\"}\",50,gauge,1607287853275\n"+
+		"app_disk_free_bytes,,1.5e+10,gauge,\n", buf.String())
+}
+
+func TestWriteMetricsNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMetrics(&buf, testFormatData(), "ndjson", false))
+	assert.Equal(t, `{"name":"jfrt_http_connections_max_total","type":"GAUGE","labels":{"max":"50","pool":"maven-remote"},"value":"50","timestamp_ms":"1607287853275"}`+"\n"+
+		`{"name":"app_disk_free_bytes","type":"GAUGE","value":"1.5e+10"}`+"\n", buf.String())
+}
+
+func TestWriteMetricsOpenMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMetrics(&buf, testFormatData(), "openmetrics", false))
+	assert.Equal(t, "# HELP jfrt_http_connections_max_total Max Connections\n"+
+		"# TYPE jfrt_http_connections_max_total gauge\n"+
+		"jfrt_http_connections_max_total{max=\"50\",pool=\"maven-remote\"} 50 1607287853.275\n"+
+		"# HELP app_disk_free_bytes Free Space\n"+
+		"# TYPE app_disk_free_bytes gauge\n"+
+		"app_disk_free_bytes 1.5e+10\n"+
+		"# EOF\n", buf.String())
+
+	buf.Reset()
+	untyped := []Data{{Name: "jfrt_custom", Type: "UNTYPED", Metric: []Metrics{{Value: "1"}}}}
+	assert.NoError(t, WriteMetrics(&buf, untyped, "openmetrics", false))
+	assert.Equal(t, "# TYPE jfrt_custom unknown\njfrt_custom 1\n# EOF\n", buf.String())
+
+	//counter families drop _total, their samples carry it
+	buf.Reset()
+	counters := []Data{
+		{Name: "sys_cpu_totaltime_seconds", Help: "CPU \"total\" time\nin seconds", Type: "COUNTER", Metric: []Metrics{{Value: "12"}}},
+		{Name: "jfrt_artifacts_gc_binaries_total", Type: "COUNTER", Metric: []Metrics{{Value: "3", Labels: LabelSet{"type": "full"}}}},
+	}
+	assert.NoError(t, WriteMetrics(&buf, counters, "openmetrics", false))
+	assert.Equal(t, "# HELP sys_cpu_totaltime_seconds CPU \\\"total\\\" time\\nin seconds\n"+
+		"# TYPE sys_cpu_totaltime_seconds counter\n"+
+		"sys_cpu_totaltime_seconds_total 12\n"+
+		"# TYPE jfrt_artifacts_gc_binaries counter\n"+
+		"jfrt_artifacts_gc_binaries_total{type=\"full\"} 3\n"+
+		"# EOF\n", buf.String())
+}
+
+func TestWriteMetricsUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, WriteMetrics(&buf, testFormatData(), "xml", false))
+}
@@ -0,0 +1,83 @@
+package helpers
+
+import (
+	"math"
+	"sort"
+	"strconv"
+)
+
+//Bucket one cumulative histogram bucket
+type Bucket struct {
+	UpperBound float64
+	Count      float64
+}
+
+//IsDistribution true for histogram and summary series, which carry buckets/quantiles instead of a single value
+func (m Metrics) IsDistribution() bool {
+	return m.Buckets != nil || m.Quantiles != nil
+}
+
+//SortedBuckets histogram buckets ordered by upper bound, +Inf last
+func (m Metrics) SortedBuckets() []Bucket {
+	var buckets []Bucket
+	for bound, count := range m.Buckets {
+		upper, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(count, 64)
+		if err != nil {
+			continue
+		}
+		buckets = append(buckets, Bucket{upper, value})
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].UpperBound < buckets[j].UpperBound })
+	return buckets
+}
+
+//Quantile value at quantile q (0-1). Summaries report it directly, histograms are estimated by linear interpolation
+//inside the matching bucket the same way Prometheus' histogram_quantile does
+func (m Metrics) Quantile(q float64) (float64, bool) {
+	for quantile, value := range m.Quantiles {
+		qq, err := strconv.ParseFloat(quantile, 64)
+		if err == nil && qq == q {
+			v, err := strconv.ParseFloat(value, 64)
+			return v, err == nil && !math.IsNaN(v)
+		}
+	}
+
+	buckets := m.SortedBuckets()
+	if len(buckets) == 0 {
+		return 0, false
+	}
+	total := buckets[len(buckets)-1].Count
+	if count, err := strconv.ParseFloat(m.Count, 64); err == nil && !math.IsInf(buckets[len(buckets)-1].UpperBound, 1) {
+		total = count
+	}
+	if total == 0 {
+		return 0, false
+	}
+
+	rank := q * total
+	lowerBound, lowerCount := 0.0, 0.0
+	for i, b := range buckets {
+		if b.Count >= rank {
+			if math.IsInf(b.UpperBound, 1) {
+				//can't interpolate into +Inf, report the highest finite bound
+				if i == 0 {
+					return 0, false
+				}
+				return buckets[i-1].UpperBound, true
+			}
+			if b.Count == lowerCount {
+				return b.UpperBound, true
+			}
+			if i == 0 && b.UpperBound <= 0 {
+				return b.UpperBound, true
+			}
+			return lowerBound + (b.UpperBound-lowerBound)*(rank-lowerCount)/(b.Count-lowerCount), true
+		}
+		lowerBound, lowerCount = b.UpperBound, b.Count
+	}
+	return buckets[len(buckets)-1].UpperBound, true
+}
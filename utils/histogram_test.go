@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const histogramText = `# HELP jfrt_http_request_duration_seconds Request latency
+# TYPE jfrt_http_request_duration_seconds histogram
+jfrt_http_request_duration_seconds_bucket{le="0.1"} 50
+jfrt_http_request_duration_seconds_bucket{le="0.5"} 90
+jfrt_http_request_duration_seconds_bucket{le="1"} 100
+jfrt_http_request_duration_seconds_bucket{le="+Inf"} 100
+jfrt_http_request_duration_seconds_sum 20
+jfrt_http_request_duration_seconds_count 100
+# HELP jfrt_db_query_seconds Query latency
+# TYPE jfrt_db_query_seconds summary
+jfrt_db_query_seconds{quantile="0.5"} 0.01
+jfrt_db_query_seconds{quantile="0.99"} 0.2
+jfrt_db_query_seconds_sum 3
+jfrt_db_query_seconds_count 150
+`
+
+func TestHistogramAndSummaryPreserved(t *testing.T) {
+	data := parseTestMetrics(t, histogramText)
+	assert.Len(t, data, 2)
+
+	histogram := data[1].Metric[0]
+	assert.Equal(t, "100", histogram.Count)
+	assert.Equal(t, "20", histogram.Sum)
+	assert.Len(t, histogram.SortedBuckets(), 4)
+
+	p50, ok := histogram.Quantile(0.5)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.1, p50, 1e-9)
+	p95, ok := histogram.Quantile(0.95)
+	assert.True(t, ok)
+	assert.InDelta(t, 0.75, p95, 1e-9)
+
+	p99, ok := data[0].Metric[0].Quantile(0.99)
+	assert.True(t, ok)
+	assert.Equal(t, 0.2, p99)
+	_, ok = data[0].Metric[0].Quantile(0.95)
+	assert.False(t, ok)
+}
+
+func TestFlattenSeriesExpandsDistributions(t *testing.T) {
+	series := FlattenSeries(parseTestMetrics(t, histogramText))
+	var names []string
+	for _, s := range series {
+		names = append(names, SeriesKey(s.Name, s.Labels)+" "+s.Value)
+	}
+	assert.Equal(t, []string{
+		`jfrt_db_query_seconds{quantile="0.5"} 0.01`,
+		`jfrt_db_query_seconds{quantile="0.99"} 0.2`,
+		`jfrt_db_query_seconds_sum 3`,
+		`jfrt_db_query_seconds_count 150`,
+		`jfrt_http_request_duration_seconds_bucket{le="0.1"} 50`,
+		`jfrt_http_request_duration_seconds_bucket{le="0.5"} 90`,
+		`jfrt_http_request_duration_seconds_bucket{le="1"} 100`,
+		`jfrt_http_request_duration_seconds_bucket{le="+Inf"} 100`,
+		`jfrt_http_request_duration_seconds_sum 20`,
+		`jfrt_http_request_duration_seconds_count 100`,
+	}, names)
+}
@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"strconv"
+	"time"
+)
+
+//LabelSet every label of a series as Artifactory, Xray or Router sent it
+type LabelSet map[string]string
+
+//Map copy of the label set, safe to add labels to
+func (l LabelSet) Map() map[string]string {
+	labels := make(map[string]string, len(l))
+	for name, value := range l {
+		labels[name] = value
+	}
+	return labels
+}
+
+//Get value of a label, empty when it is not set
+func (l LabelSet) Get(name string) string {
+	return l[name]
+}
+
+//Pool remote repository connection pool of jfrt_http_connections_* series
+func (l LabelSet) Pool() string {
+	return l["pool"]
+}
+
+//PoolMax configured max connections of the pool, false when missing or not a number
+func (l LabelSet) PoolMax() (int, bool) {
+	max, err := strconv.Atoi(l["max"])
+	return max, err == nil
+}
+
+//GcStart start of the garbage collection run of jfrt_artifacts_gc_* series
+func (l LabelSet) GcStart() (time.Time, bool) {
+	return epochMsLabel(l["start"])
+}
+
+//GcEnd end of the garbage collection run of jfrt_artifacts_gc_* series
+func (l LabelSet) GcEnd() (time.Time, bool) {
+	return epochMsLabel(l["end"])
+}
+
+//GcStatus status of the garbage collection run, e.g. COMPLETED
+func (l LabelSet) GcStatus() string {
+	return l["status"]
+}
+
+//GcType type of the garbage collection run, e.g. FULL
+func (l LabelSet) GcType() string {
+	return l["type"]
+}
+
+func epochMsLabel(value string) (time.Time, bool) {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(ms/1000, ms%1000*int64(time.Millisecond)), true
+}
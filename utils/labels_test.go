@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelSetKeepsEveryLabel(t *testing.T) {
+	data := parseTestMetrics(t, `# HELP jfxr_db_sync_running_total Sync status
+# TYPE jfxr_db_sync_running_total gauge
+jfxr_db_sync_running_total{node_id="xray-1",sync_type="onboarding"} 1
+# HELP jfrt_artifacts_gc_duration_seconds Time taken by Garbage Collection
+# TYPE jfrt_artifacts_gc_duration_seconds gauge
+jfrt_artifacts_gc_duration_seconds{end="1607284801199",start="1607284800142",status="COMPLETED",type="FULL"} 1.057
+`)
+	labels := data[1].Metric[0].Labels
+	assert.Equal(t, "xray-1", labels.Get("node_id"))
+	assert.Equal(t, "onboarding", labels.Get("sync_type"))
+
+	selected, err := SelectMetrics(data, `{sync_type="onboarding"}`)
+	assert.NoError(t, err)
+	assert.Len(t, selected, 1)
+
+	gc := data[0].Metric[0].Labels
+	start, ok := gc.GcStart()
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(1607284800, 142*int64(time.Millisecond)), start)
+	assert.Equal(t, "COMPLETED", gc.GcStatus())
+	assert.Equal(t, "FULL", gc.GcType())
+	_, ok = LabelSet{"max": "fifty"}.PoolMax()
+	assert.False(t, ok)
+}
@@ -0,0 +1,197 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+)
+
+//HANodesAPI the node list of an Artifactory HA cluster, relative to the Artifactory URL
+const HANodesAPI = "api/system/ha/nodes"
+
+//NodeLabel label every series polled from a single HA node is tagged with
+const NodeLabel = "node"
+
+//Node one node of an Artifactory HA cluster, URL is its own Artifactory URL bypassing the load balancer
+type Node struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	State string `json:"state,omitempty"`
+}
+
+//ParseNode a node given as id=url, or as a bare url named after its host
+func ParseNode(value string) (Node, error) {
+	node := Node{URL: value}
+	if i := strings.Index(value, "="); i > 0 && !strings.Contains(value[:i], "/") {
+		node.ID, node.URL = value[:i], value[i+1:]
+	}
+	parsed, err := url.Parse(node.URL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return Node{}, errors.New("Invalid node " + value + ", expected id=url or url, e.g. art1=http://10.0.0.1:8081/artifactory/")
+	}
+	if node.ID == "" {
+		node.ID = parsed.Hostname()
+	}
+	node.URL = strings.TrimSuffix(node.URL, "/") + "/"
+	return node, nil
+}
+
+//DiscoverNodes list the nodes of the cluster behind the load balanced URL of config
+func DiscoverNodes(config *config.ArtifactoryDetails) ([]Node, error) {
+	body, respCode, _ := GetRestAPI("GET", true, config.Url+HANodesAPI, config.User, config.Password, "", nil, 1)
+	if respCode != 200 {
+		return nil, errors.New("Failed to discover the HA nodes, received " + strconv.Itoa(respCode) + " from " + config.Url + HANodesAPI + ". List them with --node instead")
+	}
+	//the list is either the body itself or under nodes
+	var nodes []Node
+	if err := json.Unmarshal(body, &nodes); err != nil {
+		var wrapped struct {
+			Nodes []Node `json:"nodes"`
+		}
+		if err := json.Unmarshal(body, &wrapped); err != nil {
+			return nil, errors.New("Invalid HA node list from " + config.Url + HANodesAPI + ": " + err.Error())
+		}
+		nodes = wrapped.Nodes
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("No HA nodes listed by " + config.Url + HANodesAPI)
+	}
+	for i := range nodes {
+		if nodes[i].ID == "" || nodes[i].URL == "" {
+			return nil, errors.New("HA node " + strconv.Itoa(i+1) + " listed by " + config.Url + HANodesAPI + " has no id or url")
+		}
+		nodes[i].URL = strings.TrimSuffix(nodes[i].URL, "/") + "/"
+	}
+	return nodes, nil
+}
+
+//NodeConfig copy of config pointed at a single node, with the same credentials
+func NodeConfig(config *config.ArtifactoryDetails, node Node) *config.ArtifactoryDetails {
+	nodeConfig := *config
+	nodeConfig.Url = node.URL
+	return &nodeConfig
+}
+
+//TagNode label every series of data with the node it was polled from, in place
+func TagNode(data []Data, node string) []Data {
+	for i := range data {
+		for j := range data[i].Metric {
+			labels := data[i].Metric[j].Labels.Map()
+			labels[NodeLabel] = node
+			data[i].Metric[j].Labels = labels
+		}
+	}
+	return data
+}
+
+//sharedFamilies how AggregateNodes combines the values of families the nodes of a cluster share rather than add up:
+//the filestore they all write to. The least free space so the summed up storage is never better than what a node
+//sees. Every other family is summed, counters, per node usage and per node limits such as the DB and HTTP connection
+//pools add up
+var sharedFamilies = map[string]func(a, b string) string{
+	"app_disk_total_bytes": maxValues,
+	"app_disk_free_bytes":  minValues,
+}
+
+//AggregateNodes the data of every node in one set: series sharing a name and labels, node aside, are summed, but for
+//sharedFamilies. So are histogram buckets, counts and sums, quantiles take the highest node. A family is as stale as
+//its stalest node
+func AggregateNodes(nodes ...[]Data) []Data {
+	var families []Data
+	familyIndex := make(map[string]int)
+	seriesIndex := make(map[string]int)
+	for _, data := range nodes {
+		for _, d := range data {
+			fi, ok := familyIndex[d.Name]
+			if !ok {
+				fi = len(families)
+				familyIndex[d.Name] = fi
+				families = append(families, Data{Name: d.Name, Help: d.Help, Type: d.Type, Updated: d.Updated})
+			} else if d.Updated != "" && (families[fi].Updated == "" || StringToInt64(d.Updated) < StringToInt64(families[fi].Updated)) {
+				families[fi].Updated = d.Updated
+			}
+			for _, m := range d.Metric {
+				labels := m.Labels.Map()
+				delete(labels, NodeLabel)
+				key := d.Name + FormatLabels(labels)
+				si, ok := seriesIndex[key]
+				if !ok {
+					seriesIndex[key] = len(families[fi].Metric)
+					m.Labels = labels
+					m.Buckets, m.Quantiles = copyValues(m.Buckets), copyValues(m.Quantiles)
+					families[fi].Metric = append(families[fi].Metric, m)
+					continue
+				}
+				combine, ok := sharedFamilies[d.Name]
+				if !ok {
+					combine = addValues
+				}
+				sum := &families[fi].Metric[si]
+				sum.Value = combine(sum.Value, m.Value)
+				sum.Count = addValues(sum.Count, m.Count)
+				sum.Sum = addValues(sum.Sum, m.Sum)
+				for bucket, value := range m.Buckets {
+					sum.Buckets[bucket] = addValues(sum.Buckets[bucket], value)
+				}
+				for quantile, value := range m.Quantiles {
+					sum.Quantiles[quantile] = maxValues(sum.Quantiles[quantile], value)
+				}
+				if m.TimestampMs != "" && (sum.TimestampMs == "" || StringToInt64(m.TimestampMs) > StringToInt64(sum.TimestampMs)) {
+					sum.TimestampMs = m.TimestampMs
+				}
+			}
+		}
+	}
+	return families
+}
+
+func copyValues(values map[string]string) map[string]string {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(values))
+	for k, v := range values {
+		copied[k] = v
+	}
+	return copied
+}
+
+//addValues sum of two exported values, a when b is missing or either is not a number
+func addValues(a, b string) string {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil {
+		return b
+	}
+	if errB != nil {
+		return a
+	}
+	return strconv.FormatFloat(x+y, 'f', -1, 64)
+}
+
+//maxValues the higher of two exported values, NaN quantiles of idle nodes are ignored
+func maxValues(a, b string) string {
+	return pickValue(a, b, math.Max)
+}
+
+//minValues the lower of two exported values, NaN is ignored
+func minValues(a, b string) string {
+	return pickValue(a, b, math.Min)
+}
+
+func pickValue(a, b string, pick func(x, y float64) float64) string {
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || math.IsNaN(x) {
+		return b
+	}
+	if errB != nil || math.IsNaN(y) {
+		return a
+	}
+	return strconv.FormatFloat(pick(x, y), 'f', -1, 64)
+}
@@ -0,0 +1,146 @@
+package helpers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jfrog/jfrog-cli-core/utils/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNode(t *testing.T) {
+	node, err := ParseNode("art1=http://10.0.0.1:8081/artifactory")
+	assert.NoError(t, err)
+	assert.Equal(t, Node{ID: "art1", URL: "http://10.0.0.1:8081/artifactory/"}, node)
+
+	node, err = ParseNode("http://art2.local:8081/artifactory/?a=b")
+	assert.NoError(t, err)
+	assert.Equal(t, "art2.local", node.ID)
+
+	for _, value := range []string{"art1", "art1=", "art1=10.0.0.1", "://"} {
+		_, err = ParseNode(value)
+		assert.Error(t, err, value)
+	}
+}
+
+//haStandIn the load balanced URL of a cluster, listing its nodes the way body says
+func haStandIn(t *testing.T, status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "/artifactory/"+HANodesAPI, r.URL.Path)
+		assert.Equal(t, "admin:password", user+":"+password)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestDiscoverNodes(t *testing.T) {
+	tests := map[string]string{
+		`[{"id": "art1", "url": "http://10.0.0.1:8081/artifactory", "state": "RUNNING"}, {"id": "art2", "url": "http://10.0.0.2:8081/artifactory/"}]`: "",
+		`{"nodes": [{"id": "art1", "url": "http://10.0.0.1:8081/artifactory/"}, {"id": "art2", "url": "http://10.0.0.2:8081/artifactory"}]}`:          "",
+		`[]`:               "No HA nodes",
+		`[{"id": "art1"}]`: "has no id or url",
+		`not json`:         "Invalid HA node list",
+	}
+	for body, message := range tests {
+		server := haStandIn(t, http.StatusOK, body)
+		nodes, err := DiscoverNodes(&config.ArtifactoryDetails{Url: server.URL + "/artifactory/", User: "admin", Password: "password"})
+		server.Close()
+		if message != "" {
+			if assert.Error(t, err, body) {
+				assert.Contains(t, err.Error(), message)
+			}
+			continue
+		}
+		assert.NoError(t, err)
+		if assert.Len(t, nodes, 2) {
+			assert.Equal(t, "art1", nodes[0].ID)
+			assert.Equal(t, "http://10.0.0.1:8081/artifactory/", nodes[0].URL)
+			assert.Equal(t, "http://10.0.0.2:8081/artifactory/", nodes[1].URL)
+		}
+	}
+
+	server := haStandIn(t, http.StatusNotFound, "")
+	defer server.Close()
+	_, err := DiscoverNodes(&config.ArtifactoryDetails{Url: server.URL + "/artifactory/", User: "admin", Password: "password"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "received 404")
+	}
+}
+
+func TestNodeConfig(t *testing.T) {
+	lb := &config.ArtifactoryDetails{ServerId: "ha", Url: "http://lb/artifactory/", User: "admin", Password: "password"}
+	nodeConfig := NodeConfig(lb, Node{ID: "art1", URL: "http://10.0.0.1:8081/artifactory/"})
+	assert.Equal(t, "http://10.0.0.1:8081/artifactory/", nodeConfig.Url)
+	assert.Equal(t, "admin", nodeConfig.User)
+	assert.Equal(t, "http://lb/artifactory/", lb.Url)
+}
+
+func TestAggregateNodes(t *testing.T) {
+	node1 := TagNode([]Data{
+		{Name: "jfrt_db_connections_active_total", Type: "GAUGE", Updated: "2000", Metric: []Metrics{{Value: "4", TimestampMs: "10"}}},
+		{Name: "jfrt_http_connections_leased_total", Metric: []Metrics{
+			{Value: "2", Labels: LabelSet{"pool": "npm-remote"}},
+			{Value: "5", Labels: LabelSet{"pool": "maven-remote"}},
+		}},
+		{Name: "latency_seconds", Type: "SUMMARY", Metric: []Metrics{{Quantiles: map[string]string{"0.5": "0.1", "0.99": "NaN"}, Count: "10", Sum: "1.5"}}},
+	}, "art1")
+	assert.Equal(t, "art1", node1[1].Metric[0].Labels.Get(NodeLabel))
+	node2 := TagNode([]Data{
+		{Name: "jfrt_db_connections_active_total", Type: "GAUGE", Updated: "1000", Metric: []Metrics{{Value: "6", TimestampMs: "20"}}},
+		{Name: "jfrt_http_connections_leased_total", Metric: []Metrics{{Value: "3", Labels: LabelSet{"pool": "npm-remote"}}}},
+		{Name: "latency_seconds", Type: "SUMMARY", Metric: []Metrics{{Quantiles: map[string]string{"0.5": "0.3", "0.99": "2"}, Count: "5", Sum: "2"}}},
+		{Name: "only_on_art2", Metric: []Metrics{{Value: "1"}}},
+	}, "art2")
+
+	all := AggregateNodes(node1, node2)
+	assert.Len(t, all, 4)
+	assert.Equal(t, "10", all[0].Metric[0].Value)
+	assert.Equal(t, "20", all[0].Metric[0].TimestampMs)
+	assert.Equal(t, "1000", all[0].Updated)
+	assert.Equal(t, LabelSet{}, all[0].Metric[0].Labels)
+	assert.Equal(t, "5", all[1].Metric[0].Value)
+	assert.Equal(t, LabelSet{"pool": "npm-remote"}, all[1].Metric[0].Labels)
+	assert.Equal(t, "5", all[1].Metric[1].Value)
+	assert.Equal(t, map[string]string{"0.5": "0.3", "0.99": "2"}, all[2].Metric[0].Quantiles)
+	assert.Equal(t, "15", all[2].Metric[0].Count)
+	assert.Equal(t, "3.5", all[2].Metric[0].Sum)
+	assert.Equal(t, "only_on_art2", all[3].Name)
+	//the nodes' own data is left alone
+	assert.Equal(t, "4", node1[0].Metric[0].Value)
+	assert.Equal(t, "0.1", node1[2].Metric[0].Quantiles["0.5"])
+}
+
+//TestAggregateNodesShared the filestore every node reports is not added up, the DB pools of the nodes are
+func TestAggregateNodesShared(t *testing.T) {
+	node := func(id, free string) []Data {
+		return TagNode([]Data{
+			{Name: "app_disk_total_bytes", Type: "GAUGE", Metric: []Metrics{{Value: "1000"}}},
+			{Name: "app_disk_free_bytes", Type: "GAUGE", Metric: []Metrics{{Value: free}}},
+			{Name: "jfrt_db_connections_max_active_total", Type: "GAUGE", Metric: []Metrics{{Value: "100"}}},
+			{Name: "jfrt_db_connections_active_total", Type: "GAUGE", Metric: []Metrics{{Value: "4"}}},
+		}, id)
+	}
+
+	//series exported without a timestamp are combined without conversion warnings
+	var log bytes.Buffer
+	out := LogRestFile.Out
+	LogRestFile.Out = &log
+	defer func() { LogRestFile.Out = out }()
+
+	all := AggregateNodes(node("art1", "375"), node("art2", "375"))
+	assert.Equal(t, "", log.String())
+	assert.Equal(t, "", all[0].Metric[0].TimestampMs)
+	assert.Equal(t, "1000", all[0].Metric[0].Value)
+	assert.Equal(t, "375", all[1].Metric[0].Value)
+	assert.Equal(t, "200", all[2].Metric[0].Value)
+	assert.Equal(t, "8", all[3].Metric[0].Value)
+
+	//polled a moment apart, the least free space wins
+	all = AggregateNodes(node("art1", "375"), node("art2", "370"), node("art3", "380"))
+	assert.Equal(t, "1000", all[0].Metric[0].Value)
+	assert.Equal(t, "370", all[1].Metric[0].Value)
+	assert.Equal(t, "12", all[3].Metric[0].Value)
+}
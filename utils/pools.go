@@ -0,0 +1,129 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v2"
+)
+
+//ConnectionPoolPrefix name prefix of the per remote repository connection pool families
+const ConnectionPoolPrefix = "jfrt_http_connections_"
+
+//ConnectionPool remote repository HTTP connection pool, built from the jfrt_http_connections_* series sharing a pool label
+type ConnectionPool struct {
+	Name      string `json:"name" yaml:"name"`
+	Leased    int    `json:"leased" yaml:"leased"`
+	Pending   int    `json:"pending" yaml:"pending"`
+	Max       int    `json:"max" yaml:"max"`
+	Available int    `json:"available" yaml:"available"`
+}
+
+//GetConnectionPools group the connection pool series by their pool label, sorted by pool name
+func GetConnectionPools(data []Data) []ConnectionPool {
+	pools := make(map[string]*ConnectionPool)
+	for i := range data {
+		if !strings.HasPrefix(data[i].Name, ConnectionPoolPrefix) {
+			continue
+		}
+		kind := strings.TrimSuffix(strings.TrimPrefix(data[i].Name, ConnectionPoolPrefix), "_total")
+		for _, m := range data[i].Metric {
+			name := m.Labels.Pool()
+			pool, ok := pools[name]
+			if !ok {
+				pool = &ConnectionPool{Name: name}
+				pools[name] = pool
+			}
+			value, err := strconv.ParseFloat(m.Value, 64)
+			if err != nil {
+				LogRestFile.Warn("Failed to convert number ", m.Value, " for pool ", name)
+				continue
+			}
+			switch kind {
+			case "leased":
+				pool.Leased = int(value)
+			case "pending":
+				pool.Pending = int(value)
+			case "max":
+				pool.Max = int(value)
+			case "available":
+				pool.Available = int(value)
+			}
+			//older versions only expose the max as a label
+			if pool.Max == 0 {
+				if max, ok := m.Labels.PoolMax(); ok {
+					pool.Max = max
+				}
+			}
+		}
+	}
+
+	var result []ConnectionPool
+	for _, pool := range pools {
+		result = append(result, *pool)
+	}
+	sort.Slice(result, func(i, j int) bool { return strings.ToLower(result[i].Name) < strings.ToLower(result[j].Name) })
+	return result
+}
+
+//PoolTotals sum of every pool
+func PoolTotals(pools []ConnectionPool) ConnectionPool {
+	total := ConnectionPool{Name: "total"}
+	for _, pool := range pools {
+		total.Leased += pool.Leased
+		total.Pending += pool.Pending
+		total.Max += pool.Max
+		total.Available += pool.Available
+	}
+	return total
+}
+
+//WritePools write the pool model as json, table, csv or yaml
+func WritePools(w io.Writer, pools []ConnectionPool, format string, pretty bool) error {
+	switch format {
+	case "", "json":
+		var jsonText []byte
+		var err error
+		if pretty {
+			jsonText, err = json.MarshalIndent(pools, "", "    ")
+		} else {
+			jsonText, err = json.Marshal(pools)
+		}
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(jsonText))
+		return err
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "POOL\tLEASED\tPENDING\tAVAILABLE\tMAX")
+		rows := append(append([]ConnectionPool{}, pools...), PoolTotals(pools))
+		for _, pool := range rows {
+			fmt.Fprintln(tw, pool.Name+"\t"+strconv.Itoa(pool.Leased)+"\t"+strconv.Itoa(pool.Pending)+"\t"+strconv.Itoa(pool.Available)+"\t"+strconv.Itoa(pool.Max))
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"pool", "leased", "pending", "available", "max"})
+		for _, pool := range pools {
+			cw.Write([]string{pool.Name, strconv.Itoa(pool.Leased), strconv.Itoa(pool.Pending), strconv.Itoa(pool.Available), strconv.Itoa(pool.Max)})
+		}
+		cw.Flush()
+		return cw.Error()
+	case "yaml":
+		yamlText, err := yaml.Marshal(pools)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(yamlText)
+		return err
+	}
+	return errors.New("Unsupported format " + format + " for pools, expected one of: json, table, csv, yaml")
+}
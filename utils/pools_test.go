@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//two pools, each repeating the family block the way Artifactory does
+const poolsText = `# HELP jfrt_http_connections_available_total Available Connections
+# UPDATED jfrt_http_connections_available_total 1607287853000
+# TYPE jfrt_http_connections_available_total gauge
+jfrt_http_connections_available_total{max="50",pool="npm-remote"} 48 1607287853275
+# HELP jfrt_http_connections_leased_total Leased Connections
+# UPDATED jfrt_http_connections_leased_total 1607287853000
+# TYPE jfrt_http_connections_leased_total gauge
+jfrt_http_connections_leased_total{max="50",pool="npm-remote"} 2 1607287853275
+# HELP jfrt_http_connections_max_total Max Connections
+# UPDATED jfrt_http_connections_max_total 1607287853000
+# TYPE jfrt_http_connections_max_total gauge
+jfrt_http_connections_max_total{max="50",pool="npm-remote"} 50 1607287853275
+# HELP jfrt_http_connections_pending_total Pending Connections
+# UPDATED jfrt_http_connections_pending_total 1607287853000
+# TYPE jfrt_http_connections_pending_total gauge
+jfrt_http_connections_pending_total{max="50",pool="npm-remote"} 0 1607287853275
+# HELP jfrt_http_connections_available_total Available Connections
+# UPDATED jfrt_http_connections_available_total 1607287854000
+# TYPE jfrt_http_connections_available_total gauge
+jfrt_http_connections_available_total{max="20",pool="maven-remote"} 15 1607287853275
+# HELP jfrt_http_connections_leased_total Leased Connections
+# UPDATED jfrt_http_connections_leased_total 1607287854000
+# TYPE jfrt_http_connections_leased_total gauge
+jfrt_http_connections_leased_total{max="20",pool="maven-remote"} 5 1607287853275
+# HELP jfrt_http_connections_max_total Max Connections
+# UPDATED jfrt_http_connections_max_total 1607287854000
+# TYPE jfrt_http_connections_max_total gauge
+jfrt_http_connections_max_total{max="20",pool="maven-remote"} 20 1607287853275
+# HELP jfrt_http_connections_pending_total Pending Connections
+# UPDATED jfrt_http_connections_pending_total 1607287854000
+# TYPE jfrt_http_connections_pending_total gauge
+jfrt_http_connections_pending_total{max="20",pool="maven-remote"} 1 1607287853275`
+
+func TestRepeatedFamiliesKeepRealNames(t *testing.T) {
+	data := parseTestMetrics(t, poolsText)
+	assert.Len(t, data, 4)
+	for i := range data {
+		assert.Contains(t, data[i].Name, ConnectionPoolPrefix)
+		assert.Len(t, data[i].Metric, 2)
+	}
+}
+
+func TestGetConnectionPools(t *testing.T) {
+	pools := GetConnectionPools(parseTestMetrics(t, poolsText))
+	assert.Equal(t, []ConnectionPool{
+		{Name: "maven-remote", Leased: 5, Pending: 1, Max: 20, Available: 15},
+		{Name: "npm-remote", Leased: 2, Pending: 0, Max: 50, Available: 48},
+	}, pools)
+	assert.Equal(t, ConnectionPool{Name: "total", Leased: 7, Pending: 1, Max: 70, Available: 63}, PoolTotals(pools))
+}
+
+func TestUpdatedMetadata(t *testing.T) {
+	data := parseTestMetrics(t, poolsText)
+	//the repeated blocks carry different UPDATED values, the latest wins
+	assert.Equal(t, "1607287854000", data[0].Updated)
+	updated, ok := data[0].UpdatedTime()
+	assert.True(t, ok)
+	assert.True(t, data[0].IsStale(time.Minute, updated.Add(2*time.Minute)))
+	assert.False(t, data[0].IsStale(time.Minute, updated.Add(30*time.Second)))
+	assert.False(t, Data{Name: "no_updated"}.IsStale(time.Minute, updated))
+}
@@ -0,0 +1,106 @@
+package helpers
+
+import (
+	"strconv"
+	"time"
+)
+
+type rateSample struct {
+	time  time.Time
+	value float64
+}
+
+//RateTracker keeps recent samples of every series across polls to derive counter rates. A value lower than the
+//previous sample is treated as a counter reset (e.g. Artifactory restarted) and counted from zero
+type RateTracker struct {
+	//Window how much history Increase and Resets look at
+	Window  time.Duration
+	samples map[string][]rateSample
+}
+
+//NewRateTracker tracker keeping window worth of samples
+func NewRateTracker(window time.Duration) *RateTracker {
+	return &RateTracker{Window: window, samples: make(map[string][]rateSample)}
+}
+
+//Observe record one poll. Series are keyed by SeriesKey
+func (rt *RateTracker) Observe(data []Data, t time.Time) {
+	for _, s := range FlattenSeries(data) {
+		value, err := strconv.ParseFloat(s.Value, 64)
+		if err != nil {
+			continue
+		}
+		rt.ObserveValue(SeriesKey(s.Name, s.Labels), value, t)
+	}
+	rt.Forget(t.Add(-rt.Window))
+}
+
+//ObserveValue record a single sample
+func (rt *RateTracker) ObserveValue(key string, value float64, t time.Time) {
+	samples := append(rt.samples[key], rateSample{t, value})
+	//keep one sample older than the window so Increase covers all of it
+	cutoff := t.Add(-rt.Window)
+	drop := 0
+	for drop < len(samples)-2 && samples[drop+1].time.Before(cutoff) {
+		drop++
+	}
+	rt.samples[key] = samples[drop:]
+}
+
+//increase between two consecutive samples, reset aware
+func increase(prev, cur float64) (float64, bool) {
+	if cur < prev {
+		return cur, true
+	}
+	return cur - prev, false
+}
+
+//Rate per second rate between the last two samples, false until two samples exist
+func (rt *RateTracker) Rate(key string) (float64, bool) {
+	samples := rt.samples[key]
+	if len(samples) < 2 {
+		return 0, false
+	}
+	prev, cur := samples[len(samples)-2], samples[len(samples)-1]
+	elapsed := cur.time.Sub(prev.time).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+	inc, _ := increase(prev.value, cur.value)
+	return inc / elapsed, true
+}
+
+//Increase total increase over the retained window, summing across counter resets
+func (rt *RateTracker) Increase(key string) (float64, bool) {
+	samples := rt.samples[key]
+	if len(samples) < 2 {
+		return 0, false
+	}
+	var total float64
+	for i := 1; i < len(samples); i++ {
+		inc, _ := increase(samples[i-1].value, samples[i].value)
+		total += inc
+	}
+	return total, true
+}
+
+//Resets number of counter resets seen within the retained window
+func (rt *RateTracker) Resets(key string) int {
+	samples := rt.samples[key]
+	resets := 0
+	for i := 1; i < len(samples); i++ {
+		if _, reset := increase(samples[i-1].value, samples[i].value); reset {
+			resets++
+		}
+	}
+	return resets
+}
+
+//Forget drop series not seen since before, e.g. pools that went away
+func (rt *RateTracker) Forget(before time.Time) {
+	for key, samples := range rt.samples {
+		if samples[len(samples)-1].time.Before(before) {
+			delete(rt.samples, key)
+		}
+	}
+}
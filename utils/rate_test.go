@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateTracker(t *testing.T) {
+	rt := NewRateTracker(time.Minute)
+	start := time.Unix(1607287853, 0)
+	key := "sys_cpu_totaltime_seconds"
+
+	rt.ObserveValue(key, 100, start)
+	_, ok := rt.Rate(key)
+	assert.False(t, ok)
+
+	rt.ObserveValue(key, 110, start.Add(10*time.Second))
+	rate, ok := rt.Rate(key)
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rate)
+
+	//Artifactory restarted, the counter starts over
+	rt.ObserveValue(key, 5, start.Add(20*time.Second))
+	rate, _ = rt.Rate(key)
+	assert.Equal(t, 0.5, rate)
+	assert.Equal(t, 1, rt.Resets(key))
+	increase, _ := rt.Increase(key)
+	assert.Equal(t, 15.0, increase)
+
+	//samples older than the window fall off, apart from the one anchoring it
+	rt.ObserveValue(key, 25, start.Add(90*time.Second))
+	increase, _ = rt.Increase(key)
+	assert.Equal(t, 20.0, increase)
+	assert.Equal(t, 0, rt.Resets(key))
+}
+
+func TestRateTrackerObserve(t *testing.T) {
+	rt := NewRateTracker(time.Minute)
+	start := time.Unix(1607287853, 0)
+	rt.Observe(testSelectorData(), start)
+	data := testSelectorData()
+	data[0].Metric[0].Value = "6"
+	rt.Observe(data, start.Add(2*time.Second))
+
+	rate, ok := rt.Rate(SeriesKey("jfrt_http_connections_leased_total", map[string]string{"pool": "maven-remote", "max": "50"}))
+	assert.True(t, ok)
+	assert.Equal(t, 2.0, rate)
+}
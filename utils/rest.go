@@ -12,6 +12,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -42,51 +43,79 @@ type TraceData struct {
 
 //Data struct
 type Data struct {
-	Name   string    `json:"name"`
-	Help   string    `json:"help"`
-	Type   string    `json:"type"`
-	Metric []Metrics `json:"metrics"`
+	Name   string    `json:"name" yaml:"name"`
+	Help   string    `json:"help" yaml:"help"`
+	Type   string    `json:"type" yaml:"type"`
+	Metric []Metrics `json:"metrics" yaml:"metrics"`
+	//Updated epoch ms from Artifactory's "# UPDATED <name> <epoch>" comment
+	Updated string `json:"updated,omitempty" yaml:"updated,omitempty"`
 }
 
-//Metrics struct
-type Metrics struct {
-	TimestampMs string       `json:"timestamp_ms"`
-	Value       string       `json:"value"`
-	Labels      LabelsStruct `json:"labels,omitempty"`
+//UpdatedTime when Artifactory last refreshed the family, false when it did not say
+func (d Data) UpdatedTime() (time.Time, bool) {
+	return epochMsLabel(d.Updated)
 }
 
-//LabelsStruct struct
-type LabelsStruct struct {
-	Start  string `json:"start"`
-	End    string `json:"end"`
-	Status string `json:"status"`
-	Type   string `json:"type"`
-	Max    string `json:"max"`
-	Pool   string `json:"pool"`
+//IsStale true when the family has an UPDATED time older than threshold
+func (d Data) IsStale(threshold time.Duration, now time.Time) bool {
+	updated, ok := d.UpdatedTime()
+	return ok && now.Sub(updated) > threshold
+}
+
+//Metrics struct
+type Metrics struct {
+	TimestampMs string       `json:"timestamp_ms" yaml:"timestamp_ms"`
+	Value       string       `json:"value" yaml:"value"`
+	Labels      LabelSet     `json:"labels,omitempty" yaml:"labels,omitempty"`
+	//histogram and summary families carry these instead of Value
+	Buckets   map[string]string `json:"buckets,omitempty" yaml:"buckets,omitempty"`
+	Quantiles map[string]string `json:"quantiles,omitempty" yaml:"quantiles,omitempty"`
+	Count     string            `json:"count,omitempty" yaml:"count,omitempty"`
+	Sum       string            `json:"sum,omitempty" yaml:"sum,omitempty"`
 }
 
 //GetConfig get config from cli
 func GetConfig() (*config.ArtifactoryDetails, error) {
-	//TODO handle custom server id input
+	return GetServerConfig("")
+}
+
+//GetServerConfig get the config of a server id configured in the cli, the default server when serverID is empty
+func GetServerConfig(serverID string) (*config.ArtifactoryDetails, error) {
 	serversIds, serverIDDefault, _ := GetServersIdAndDefault()
 	if len(serversIds) == 0 {
 		return nil, errorutils.CheckError(errors.New("no Artifactory servers configured. Use the 'jfrog rt c' command to set the Artifactory server details"))
 	}
+	if serverID == "" {
+		serverID = serverIDDefault
+	} else if !containsString(serversIds, serverID) {
+		return nil, errors.New("Unknown server id " + serverID + ", configured: " + strings.Join(serversIds, ", "))
+	}
 
 	//TODO handle if user is not admin
 
-	//fmt.Print(serversIds, serverIdDefault)
-	config, _ := config.GetArtifactorySpecificConfig(serverIDDefault, true, false)
+	config, err := config.GetArtifactorySpecificConfig(serverID, true, false)
+	if err != nil {
+		return nil, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
+	}
 
 	ping, _, _ := GetRestAPI("GET", true, config.Url+"api/system/ping", config.User, config.Password, "", nil, 1)
 	if string(ping) != "OK" {
-		logFile.Error("Artifactory is not up")
-		return nil, errors.New("Artifactory is not up")
+		logFile.Error("Artifactory " + serverID + " is not up")
+		return nil, errors.New("Artifactory " + serverID + " is not up")
 	}
 
 	return config, nil
 }
 
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func GetMetricsDataRaw(config *config.ArtifactoryDetails) []byte {
 	metrics, respCode, _ := GetRestAPI("GET", true, config.Url+"api/v1/metrics", config.User, config.Password, "", nil, 1)
 	if respCode != 200 {
@@ -97,48 +126,20 @@ func GetMetricsDataRaw(config *config.ArtifactoryDetails) []byte {
 	return metrics
 }
 
-func match(s string) string {
-	i := strings.Index(s, "pool=\"")
-	if i >= 0 {
-		j := strings.Index(s, "\"}")
-		if j >= 0 {
-			return s[i+6 : j]
-		}
-	}
-	return ""
-}
-
 func GetMetricsDataJSON(config *config.ArtifactoryDetails, prettyPrint bool) ([]byte, error) {
-	metrics := GetMetricsDataRaw(config)
-	if strings.Contains(string(metrics), "jfrt_http_connections") {
-		stringsLine := strings.Split(string(metrics), "\n")
-		counter := 0
-		repCount := 0
-		for i := range stringsLine {
-
-			//doesn't work bc of help/updated.. adds repo_<metric> here. have to re think it
-			// if strings.Contains(stringsLine[i], "#") {
-			// 	continue
-			// }
-			// matchRepo := match(stringsLine[i])
-			// if matchRepo != "" {
-			// 	stringsLine[i] = matchRepo + "_" + stringsLine[i]
-			// }
-			if strings.Contains(stringsLine[i], "jfrt_http_connections") {
-				if repCount == 16 {
-					repCount = 0
-					counter++
-				}
-				stringsLine[i] = strings.ReplaceAll(stringsLine[i], "jfrt_http_connections", "a"+strconv.Itoa(counter)+"jfrt_http_connections")
-				repCount++
-			}
+	return MetricsTextToJSON(GetMetricsDataRaw(config), prettyPrint)
+}
 
-		}
-		metrics = []byte(strings.Join(stringsLine[:], "\n"))
+//family prom2json family plus the UPDATED comment prom2json drops
+type family struct {
+	*prom2json.Family
+	Updated string `json:"updated,omitempty"`
+}
 
-	}
-	file2, _ := os.OpenFile(LogFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	LogRestFile.Out = file2
+//MetricsTextToJSON convert the Artifactory exposition text to prom2json shaped JSON
+func MetricsTextToJSON(metrics []byte, prettyPrint bool) ([]byte, error) {
+	merged, updated := mergeFamilies(string(metrics))
+	metrics = []byte(merged)
 
 	mfChan := make(chan *dto.MetricFamily, 1024)
 
@@ -155,10 +156,12 @@ func GetMetricsDataJSON(config *config.ArtifactoryDetails, prettyPrint bool) ([]
 
 	//TODO: Hella inefficient?
 	//fmt.Println("before", time.Now())
-	result := []*prom2json.Family{}
+	result := []*family{}
 	for mf := range mfChan {
-		result = append(result, prom2json.NewFamily(mf))
+		result = append(result, &family{Family: prom2json.NewFamily(mf), Updated: updated[mf.GetName()]})
 	}
+	//the text parser hands families back in map order, keep the output stable
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
 
 	var jsonText []byte
 	var err error
@@ -166,21 +169,46 @@ func GetMetricsDataJSON(config *config.ArtifactoryDetails, prettyPrint bool) ([]
 	if prettyPrint {
 		jsonText, err := json.MarshalIndent(result, "", "    ")
 		if err != nil {
-			LogRestFile.Error(err.Error() + " at " + string(Trace().Fn) + " on line " + string(Trace().Line))
-			return nil, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + string(Trace().Line))
+			LogRestFile.Error(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
+			return nil, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
 		}
-		fmt.Println(string(jsonText))
 		return jsonText, nil
 	}
 	jsonText, err = json.Marshal(result)
 	if err != nil {
-		LogRestFile.Error(err.Error() + " at " + string(Trace().Fn) + " on line " + string(Trace().Line))
-		return nil, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + string(Trace().Line))
+		LogRestFile.Error(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
+		return nil, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
 	}
 	//fmt.Println("after", time.Now())
 	return jsonText, nil
 }
 
+//ParseMetricsJSON unmarshal prom2json shaped JSON into the Data model
+func ParseMetricsJSON(jsonText []byte) ([]Data, error) {
+	var metricsData []Data
+	err := json.Unmarshal(jsonText, &metricsData)
+	if err != nil {
+		return nil, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
+	}
+	return metricsData, nil
+}
+
+//LoadMetricsSnapshot read a saved capture, either `metrics --raw` text or `metrics` JSON output
+func LoadMetricsSnapshot(path string) ([]Data, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		return ParseMetricsJSON(trimmed)
+	}
+	jsonText, err := MetricsTextToJSON(content, false)
+	if err != nil {
+		return nil, err
+	}
+	return ParseMetricsJSON(jsonText)
+}
+
 //StringToInt64 self explanatory
 func StringToInt64(data string) int64 {
 	convert, err := strconv.ParseInt(data, 10, 64)
@@ -204,6 +232,14 @@ func ByteCountDecimal(b int64) string {
 	return fmt.Sprintf("%.1f%cB", float64(b)/float64(div), "kMGTPE"[exp])
 }
 
+//FormatAge human readable age, rounded to the second
+func FormatAge(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	return d.Round(time.Second).String()
+}
+
 func GetMetricsData(config *config.ArtifactoryDetails, counter int, prettyPrint bool, interval int) ([]Data, string, int, error) {
 	//log.Info("hello")
 	//TODO check if token vs password apikey
@@ -216,7 +252,7 @@ func GetMetricsData(config *config.ArtifactoryDetails, counter int, prettyPrint
 	var metricsData []Data
 	err = json.Unmarshal(jsonText, &metricsData)
 	if err != nil {
-		return nil, "", 0, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + string(Trace().Line))
+		return nil, "", 0, errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
 	}
 
 	currentTime := time.Now()
@@ -233,7 +269,7 @@ func GetMetricsData(config *config.ArtifactoryDetails, counter int, prettyPrint
 func GetServersIdAndDefault() ([]string, string, error) {
 	allConfigs, err := config.GetAllArtifactoryConfigs()
 	if err != nil {
-		return nil, "", errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + string(Trace().Line))
+		return nil, "", errors.New(err.Error() + " at " + string(Trace().Fn) + " on line " + strconv.Itoa(Trace().Line))
 	}
 	var defaultVal string
 	var serversId []string
@@ -302,9 +338,6 @@ func GetRestAPI(method string, auth bool, urlInput, userName, apiKey, providedfi
 		return nil, 0, nil
 	}
 
-	file2, _ := os.OpenFile(LogFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	LogRestFile.Out = file2
-
 	body := new(bytes.Buffer)
 	//PUT upload file
 	if method == "PUT" && providedfilepath != "" {
@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"os"
+	"strconv"
+)
+
+//RotatingFile io.Writer that rolls path over to path.1, path.2, ... once it grows past MaxBytes
+type RotatingFile struct {
+	Path       string
+	MaxBytes   int64
+	MaxBackups int
+	//Header written at the top of every new file, e.g. a CSV header
+	Header []byte
+
+	file *os.File
+	size int64
+}
+
+//NewRotatingFile open (or append to) path. maxBytes <= 0 disables rotation
+func NewRotatingFile(path string, maxBytes int64, maxBackups int, header []byte) (*RotatingFile, error) {
+	rf := &RotatingFile{Path: path, MaxBytes: maxBytes, MaxBackups: maxBackups, Header: header}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+	file, err := os.OpenFile(rf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	rf.file = file
+	rf.size = info.Size()
+	if rf.size == 0 && len(rf.Header) > 0 {
+		n, err := rf.file.Write(rf.Header)
+		rf.size += int64(n)
+		return err
+	}
+	return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return err
+	}
+	if rf.MaxBackups > 0 {
+		os.Remove(rf.Path + "." + strconv.Itoa(rf.MaxBackups))
+		for i := rf.MaxBackups - 1; i > 0; i-- {
+			os.Rename(rf.Path+"."+strconv.Itoa(i), rf.Path+"."+strconv.Itoa(i+1))
+		}
+		if err := os.Rename(rf.Path, rf.Path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(rf.Path); err != nil {
+		return err
+	}
+	return rf.open()
+}
+
+//Write rotate first when p would push the current file past MaxBytes, so a single write never straddles two files
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	if rf.MaxBytes > 0 && rf.size > int64(len(rf.Header)) && rf.size+int64(len(p)) > rf.MaxBytes {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+//Close close the current file
+func (rf *RotatingFile) Close() error {
+	return rf.file.Close()
+}
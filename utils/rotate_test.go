@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "frogvision")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "watch.csv")
+
+	rf, err := NewRotatingFile(path, 20, 2, []byte("h\n"))
+	assert.NoError(t, err)
+	for _, line := range []string{"aaaaaaaaaa\n", "bbbbbbbbbb\n", "cccccccccc\n", "dddddddddd\n"} {
+		_, err = rf.Write([]byte(line))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, rf.Close())
+
+	current, _ := ioutil.ReadFile(path)
+	first, _ := ioutil.ReadFile(path + ".1")
+	second, _ := ioutil.ReadFile(path + ".2")
+	assert.Equal(t, "h\ndddddddddd\n", string(current))
+	assert.Equal(t, "h\ncccccccccc\n", string(first))
+	assert.Equal(t, "h\nbbbbbbbbbb\n", string(second))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,244 @@
+package helpers
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//Selector PromQL-style metric selector, e.g. jfrt_http_connections_leased_total{pool=~"maven.*"}
+type Selector struct {
+	Name     string
+	NameRe   *regexp.Regexp
+	Matchers []LabelMatcher
+}
+
+//LabelMatcher single label condition inside the curly braces of a selector
+type LabelMatcher struct {
+	Name  string
+	Op    string
+	Value string
+	re    *regexp.Regexp
+}
+
+var metricNameRe = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+//ParseSelector parse a selector. A bare metric name matches exactly, anything else before the braces is treated as a name regex
+//(use {__name__=~"..."} for name regexes containing braces)
+func ParseSelector(input string) (*Selector, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, errors.New("empty metric selector")
+	}
+	sel := new(Selector)
+
+	name := input
+	var matchers string
+	if i := strings.Index(input, "{"); i >= 0 {
+		if !strings.HasSuffix(input, "}") {
+			return nil, errors.New("unterminated label matchers in selector " + input)
+		}
+		name = strings.TrimSpace(input[:i])
+		matchers = input[i+1 : len(input)-1]
+	}
+
+	if name != "" {
+		if metricNameRe.MatchString(name) {
+			sel.Name = name
+		} else {
+			re, err := regexp.Compile("^(?:" + name + ")$")
+			if err != nil {
+				return nil, errors.New("invalid metric name regex " + name + ": " + err.Error())
+			}
+			sel.NameRe = re
+		}
+	}
+
+	var err error
+	sel.Matchers, err = parseLabelMatchers(matchers)
+	if err != nil {
+		return nil, err
+	}
+	return sel, nil
+}
+
+func parseLabelMatchers(s string) ([]LabelMatcher, error) {
+	var matchers []LabelMatcher
+	s = strings.TrimSpace(s)
+	for s != "" {
+		//label name
+		i := 0
+		for i < len(s) && (s[i] == '_' || s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z' || s[i] >= '0' && s[i] <= '9') {
+			i++
+		}
+		if i == 0 {
+			return nil, errors.New("expected label name at: " + s)
+		}
+		m := LabelMatcher{Name: s[:i]}
+		s = strings.TrimSpace(s[i:])
+
+		//operator
+		for _, op := range []string{"=~", "!~", "!=", "="} {
+			if strings.HasPrefix(s, op) {
+				m.Op = op
+				break
+			}
+		}
+		if m.Op == "" {
+			return nil, errors.New("expected one of =, !=, =~, !~ after label " + m.Name)
+		}
+		s = strings.TrimSpace(s[len(m.Op):])
+
+		//quoted value
+		if s == "" || (s[0] != '"' && s[0] != '\'' && s[0] != '`') {
+			return nil, errors.New("expected quoted value for label " + m.Name)
+		}
+		end := 1
+		for end < len(s) && s[end] != s[0] {
+			if s[end] == '\\' && s[0] != '`' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return nil, errors.New("unterminated value for label " + m.Name)
+		}
+		quoted := s[:end+1]
+		if quoted[0] == '\'' {
+			quoted = "\"" + strings.ReplaceAll(quoted[1:len(quoted)-1], "\"", "\\\"") + "\""
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, errors.New("invalid value for label " + m.Name + ": " + err.Error())
+		}
+		m.Value = value
+		s = strings.TrimSpace(s[end+1:])
+
+		if m.Op == "=~" || m.Op == "!~" {
+			m.re, err = regexp.Compile("^(?:" + m.Value + ")$")
+			if err != nil {
+				return nil, errors.New("invalid regex for label " + m.Name + ": " + err.Error())
+			}
+		}
+		matchers = append(matchers, m)
+
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimSpace(s[1:])
+		} else if s != "" {
+			return nil, errors.New("expected , between label matchers at: " + s)
+		}
+	}
+	return matchers, nil
+}
+
+//Matches check a single label value, a missing label is treated as the empty string
+func (m LabelMatcher) Matches(value string) bool {
+	switch m.Op {
+	case "=":
+		return value == m.Value
+	case "!=":
+		return value != m.Value
+	case "=~":
+		return m.re.MatchString(value)
+	case "!~":
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+//MatchesName check the family name against the selector
+func (sel *Selector) MatchesName(name string) bool {
+	if sel.Name != "" && sel.Name != name {
+		return false
+	}
+	if sel.NameRe != nil && !sel.NameRe.MatchString(name) {
+		return false
+	}
+	for _, m := range sel.Matchers {
+		if m.Name == "__name__" && !m.Matches(name) {
+			return false
+		}
+	}
+	return true
+}
+
+//MatchesLabels check a series label set against the selector
+func (sel *Selector) MatchesLabels(labels map[string]string) bool {
+	for _, m := range sel.Matchers {
+		if m.Name == "__name__" {
+			continue
+		}
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+//Filter return only the families and series matching the selector, families without matching series are dropped
+func (sel *Selector) Filter(data []Data) []Data {
+	var result []Data
+	for i := range data {
+		if !sel.MatchesName(data[i].Name) {
+			continue
+		}
+		family := data[i]
+		family.Metric = nil
+		for j := range data[i].Metric {
+			if sel.MatchesLabels(data[i].Metric[j].Labels.Map()) {
+				family.Metric = append(family.Metric, data[i].Metric[j])
+			}
+		}
+		if len(family.Metric) > 0 {
+			result = append(result, family)
+		}
+	}
+	return result
+}
+
+//SelectMetrics parse each selector and return the union of what their Filter matches, in payload order. A series
+//matched by several selectors is returned once
+func SelectMetrics(data []Data, selectors ...string) ([]Data, error) {
+	var sels []*Selector
+	for _, s := range selectors {
+		sel, err := ParseSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+
+	var result []Data
+	for i := range data {
+		family := data[i]
+		family.Metric = nil
+		for j := range data[i].Metric {
+			series := data[i]
+			series.Metric = data[i].Metric[j : j+1]
+			for _, sel := range sels {
+				if len(sel.Filter([]Data{series})) > 0 {
+					family.Metric = append(family.Metric, data[i].Metric[j])
+					break
+				}
+			}
+		}
+		if len(family.Metric) > 0 {
+			result = append(result, family)
+		}
+	}
+	return result, nil
+}
+
+//MetricNamespaces known metric name prefixes: Artifactory, system, application, Xray and Router
+var MetricNamespaces = []string{"jfrt_", "sys_", "app_", "jfxr_", "jfrou_"}
+
+//MetricNamespace namespace prefix of a metric name, "other" when it has none of MetricNamespaces
+func MetricNamespace(name string) string {
+	for _, ns := range MetricNamespaces {
+		if strings.HasPrefix(name, ns) {
+			return ns
+		}
+	}
+	return "other"
+}
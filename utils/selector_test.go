@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSelectorData() []Data {
+	return []Data{
+		{Name: "jfrt_http_connections_leased_total", Type: "GAUGE", Metric: []Metrics{
+			{Value: "2", Labels: LabelSet{"pool": "maven-remote", "max": "50"}},
+			{Value: "0", Labels: LabelSet{"pool": "npm-remote", "max": "50"}},
+		}},
+		{Name: "jfrt_db_connections_active_total", Type: "GAUGE", Metric: []Metrics{{Value: "3"}}},
+		{Name: "jfrt_db_connections_max_active_total", Type: "GAUGE", Metric: []Metrics{{Value: "100"}}},
+	}
+}
+
+func TestSelectorExactName(t *testing.T) {
+	sel, err := ParseSelector("jfrt_db_connections_active_total")
+	assert.NoError(t, err)
+	result := sel.Filter(testSelectorData())
+	assert.Len(t, result, 1)
+	assert.Equal(t, "3", result[0].Metric[0].Value)
+}
+
+func TestSelectorNameRegex(t *testing.T) {
+	sel, err := ParseSelector("jfrt_db_.*")
+	assert.NoError(t, err)
+	assert.Len(t, sel.Filter(testSelectorData()), 2)
+}
+
+func TestSelectorLabelMatchers(t *testing.T) {
+	sel, err := ParseSelector(`jfrt_http_connections_leased_total{pool=~"maven.*"}`)
+	assert.NoError(t, err)
+	result := sel.Filter(testSelectorData())
+	assert.Len(t, result, 1)
+	assert.Len(t, result[0].Metric, 1)
+	assert.Equal(t, "maven-remote", result[0].Metric[0].Labels.Pool())
+
+	sel, err = ParseSelector(`{pool!="maven-remote", max="50"}`)
+	assert.NoError(t, err)
+	result = sel.Filter(testSelectorData())
+	assert.Len(t, result, 1)
+	assert.Equal(t, "npm-remote", result[0].Metric[0].Labels.Pool())
+}
+
+func TestSelectorErrors(t *testing.T) {
+	for _, input := range []string{"", `x{pool="a"`, `x{pool~"a"}`, `x{pool=a}`, `x{pool=~"("}`, `x{a="1" b="2"}`} {
+		_, err := ParseSelector(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestSelectMetricsUnion(t *testing.T) {
+	result, err := SelectMetrics(testSelectorData(), "jfrt_db_connections_active_total", `{pool="npm-remote"}`)
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, "jfrt_http_connections_leased_total", result[0].Name)
+	assert.Equal(t, "jfrt_db_connections_active_total", result[1].Name)
+
+	//overlapping selectors return each series once, in payload order
+	result, err = SelectMetrics(testSelectorData(), `{pool="npm-remote"}`, "jfrt_http_connections_leased_total")
+	assert.NoError(t, err)
+	assert.Len(t, result, 1)
+	if assert.Len(t, result[0].Metric, 2) {
+		assert.Equal(t, "maven-remote", result[0].Metric[0].Labels.Pool())
+		assert.Equal(t, "npm-remote", result[0].Metric[1].Labels.Pool())
+	}
+
+	_, err = SelectMetrics(testSelectorData(), "jfrt_db_connections_active_total", `x{a="b"`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package helpers
+
+import (
+	"sort"
+	"time"
+)
+
+//Sample one timestamped value of a series
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+//ring fixed size buffer of samples in time order, oldest at start
+type ring struct {
+	samples []Sample
+	start   int
+	n       int
+}
+
+func (r *ring) at(i int) Sample {
+	return r.samples[(r.start+i)%len(r.samples)]
+}
+
+func (r *ring) push(s Sample) {
+	if r.n < len(r.samples) {
+		r.samples[(r.start+r.n)%len(r.samples)] = s
+		r.n++
+		return
+	}
+	r.samples[r.start] = s
+	r.start = (r.start + 1) % len(r.samples)
+}
+
+//grow double the capacity, keeping the samples in order
+func (r *ring) grow() {
+	samples := make([]Sample, 2*len(r.samples))
+	for i := 0; i < r.n; i++ {
+		samples[i] = r.at(i)
+	}
+	r.samples, r.start = samples, 0
+}
+
+//SeriesStore in-memory time series keyed by series identity (see SeriesKey), keeping Retention worth of samples in a
+//ring buffer per series
+type SeriesStore struct {
+	Retention time.Duration
+	capacity  int
+	series    map[string]*ring
+}
+
+//NewSeriesStore store sized for one sample every interval over retention. Buffers grow if samples come in faster
+func NewSeriesStore(retention, interval time.Duration) *SeriesStore {
+	capacity := 2
+	if interval > 0 {
+		capacity += int(retention / interval)
+	}
+	return &SeriesStore{Retention: retention, capacity: capacity, series: make(map[string]*ring)}
+}
+
+//Add append a sample, samples older than the last one of the series are ignored
+func (s *SeriesStore) Add(key string, t time.Time, value float64) {
+	r, ok := s.series[key]
+	if !ok {
+		r = &ring{samples: make([]Sample, s.capacity)}
+		s.series[key] = r
+	}
+	if r.n > 0 && t.Before(r.at(r.n-1).Time) {
+		return
+	}
+	//a full buffer still within retention means samples come faster than the store was sized for
+	if r.n == len(r.samples) && !r.at(0).Time.Before(t.Add(-s.Retention)) {
+		r.grow()
+	}
+	r.push(Sample{t, value})
+}
+
+//Last most recent sample of a series
+func (s *SeriesStore) Last(key string) (Sample, bool) {
+	r, ok := s.series[key]
+	if !ok || r.n == 0 {
+		return Sample{}, false
+	}
+	return r.at(r.n - 1), true
+}
+
+//Range samples of a series with from <= time <= to, oldest first
+func (s *SeriesStore) Range(key string, from, to time.Time) []Sample {
+	r, ok := s.series[key]
+	if !ok {
+		return nil
+	}
+	var samples []Sample
+	for i := 0; i < r.n; i++ {
+		sample := r.at(i)
+		if !sample.Time.Before(from) && !sample.Time.After(to) {
+			samples = append(samples, sample)
+		}
+	}
+	return samples
+}
+
+//Window resample the width long window ending at end into points evenly spaced values for plotting. Each point holds
+//the latest sample at or before its time, points before the first sample are 0
+func (s *SeriesStore) Window(key string, end time.Time, width time.Duration, points int) []float64 {
+	if points < 1 {
+		return nil
+	}
+	values := make([]float64, points)
+	r, ok := s.series[key]
+	if !ok {
+		return values
+	}
+	step := width / time.Duration(points)
+	j := 0
+	var last float64
+	seen := false
+	for i := range values {
+		//counted back from end so the last point always includes the latest sample
+		t := end.Add(-step * time.Duration(points-1-i))
+		for j < r.n && !r.at(j).Time.After(t) {
+			last, seen = r.at(j).Value, true
+			j++
+		}
+		if seen {
+			values[i] = last
+		}
+	}
+	return values
+}
+
+//Keys every series in the store, sorted
+func (s *SeriesStore) Keys() []string {
+	var keys []string
+	for key := range s.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//Oldest time of the oldest sample in the store, false when it is empty
+func (s *SeriesStore) Oldest() (time.Time, bool) {
+	var oldest time.Time
+	found := false
+	for _, r := range s.series {
+		if r.n > 0 && (!found || r.at(0).Time.Before(oldest)) {
+			oldest, found = r.at(0).Time, true
+		}
+	}
+	return oldest, found
+}
+
+//Forget drop samples older than before, and series left without any
+func (s *SeriesStore) Forget(before time.Time) {
+	for key, r := range s.series {
+		for r.n > 0 && r.at(0).Time.Before(before) {
+			r.start = (r.start + 1) % len(r.samples)
+			r.n--
+		}
+		if r.n == 0 {
+			delete(s.series, key)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeriesStoreRetention(t *testing.T) {
+	start := time.Unix(1607287853, 0)
+	store := NewSeriesStore(10*time.Second, 5*time.Second)
+	for i := 0; i < 10; i++ {
+		store.Add("a", start.Add(time.Duration(i)*5*time.Second), float64(i))
+	}
+	//sized for 10s at 5s plus slack, the oldest samples were overwritten
+	samples := store.Range("a", start, start.Add(time.Hour))
+	assert.Len(t, samples, 4)
+	assert.Equal(t, float64(6), samples[0].Value)
+	last, ok := store.Last("a")
+	assert.True(t, ok)
+	assert.Equal(t, Sample{start.Add(45 * time.Second), 9}, last)
+
+	//out of order samples are ignored
+	store.Add("a", start, 100)
+	last, _ = store.Last("a")
+	assert.Equal(t, float64(9), last.Value)
+
+	_, ok = store.Last("b")
+	assert.False(t, ok)
+}
+
+func TestSeriesStoreGrows(t *testing.T) {
+	start := time.Unix(1607287853, 0)
+	store := NewSeriesStore(time.Minute, time.Minute)
+	for i := 0; i < 60; i++ {
+		store.Add("a", start.Add(time.Duration(i)*time.Second), float64(i))
+	}
+	samples := store.Range("a", start, start.Add(time.Hour))
+	assert.Len(t, samples, 60)
+	assert.Equal(t, float64(0), samples[0].Value)
+	assert.Equal(t, float64(59), samples[59].Value)
+}
+
+func TestSeriesStoreWindow(t *testing.T) {
+	start := time.Unix(1607287853, 0)
+	store := NewSeriesStore(time.Hour, time.Second)
+	store.Add("a", start.Add(10*time.Second), 1)
+	store.Add("a", start.Add(25*time.Second), 2)
+	store.Add("a", start.Add(30*time.Second), 3)
+
+	assert.Equal(t, []float64{0, 1, 1, 3}, store.Window("a", start.Add(30*time.Second), 40*time.Second, 4))
+	assert.Equal(t, []float64{0, 0, 1, 1}, store.Window("a", start.Add(20*time.Second), 40*time.Second, 4))
+	assert.Equal(t, []float64{0, 0}, store.Window("b", start, time.Minute, 2))
+	assert.Nil(t, store.Window("a", start, time.Minute, 0))
+}
+
+func TestSeriesStoreForget(t *testing.T) {
+	start := time.Unix(1607287853, 0)
+	store := NewSeriesStore(time.Hour, time.Second)
+	store.Add("a", start, 1)
+	store.Add("a", start.Add(time.Minute), 2)
+	store.Add("b", start, 1)
+	oldest, ok := store.Oldest()
+	assert.True(t, ok)
+	assert.Equal(t, start, oldest)
+
+	store.Forget(start.Add(time.Second))
+	assert.Equal(t, []string{"a"}, store.Keys())
+	oldest, _ = store.Oldest()
+	assert.Equal(t, start.Add(time.Minute), oldest)
+}